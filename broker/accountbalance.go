@@ -6,11 +6,38 @@ package broker
 import "github.com/shopspring/decimal"
 
 // AccountBalance is a representation of a broker's account balance.
+// For accounts holding several currencies the amounts are converted to the account Currency,
+// and the unconverted balance of each currency is listed in Currencies.
 type AccountBalance struct {
 
+	// Currency is the currency of the balance amounts, empty for a single currency account.
+	Currency string
+
 	// Trade is the balance amount available for trading.
 	Trade decimal.Decimal
 
 	// Equity is the total notional account value including unrealized gains on open positions.
 	Equity decimal.Decimal
+
+	// MarginUsed is the initial margin held against open positions and open orders.
+	MarginUsed decimal.Decimal
+
+	// AvailableMargin is the equity available to open new positions i.e. Equity - MarginUsed.
+	AvailableMargin decimal.Decimal
+
+	// Currencies is the balance held in each currency, empty for a single currency account.
+	Currencies []CurrencyBalance
+}
+
+// CurrencyBalance is the balance held in a single currency.
+type CurrencyBalance struct {
+
+	// Currency is the currency of the balance amounts.
+	Currency string
+
+	// Trade is the realized cash balance in the currency.
+	Trade decimal.Decimal
+
+	// Equity is the cash balance plus the unrealized gains of open positions quoted in the currency.
+	Equity decimal.Decimal
 }
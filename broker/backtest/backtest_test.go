@@ -8,11 +8,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/thecolngroup/alphakit/broker"
 	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/alphakit/web"
 	"github.com/thecolngroup/gou/dec"
 	"github.com/thecolngroup/gou/test"
+	"golang.org/x/exp/slices"
 )
 
 func TestLongTradeWithCosts(t *testing.T) {
@@ -105,3 +108,1453 @@ func TestShortTradeWithStop(t *testing.T) {
 
 	assert.True(t, act.Equal(exp))
 }
+
+func TestTradeFeesReducePNL(t *testing.T) {
+	tests := []struct {
+		name          string
+		giveSide      broker.OrderSide
+		giveExitPrice float64
+		wantOpenPNL   decimal.Decimal
+		wantProfit    decimal.Decimal
+	}{
+		{
+			name:          "long",
+			giveSide:      broker.Buy,
+			giveExitPrice: 12,
+			wantOpenPNL:   dec.New(3.8),
+			wantProfit:    dec.New(3.56),
+		},
+		{
+			name:          "short",
+			giveSide:      broker.Sell,
+			giveExitPrice: 8,
+			wantOpenPNL:   dec.New(3.8),
+			wantProfit:    dec.New(3.64),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			exit := dec.New(tt.giveExitPrice)
+			prices := []market.Kline{
+				{Start: start, O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)},
+				{Start: start.Add(time.Hour), O: exit, H: exit, L: exit, C: exit},
+			}
+
+			dealer := NewDealerWithCost(&PerpCoster{TransactionPct: dec.New(0.01)})
+			assert.NoError(t, dealer.ReceivePrice(context.Background(), prices[0]))
+			_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, tt.giveSide, dec.New(2)))
+			assert.NoError(t, err)
+			assert.NoError(t, dealer.ReceivePrice(context.Background(), prices[1]))
+
+			// Entry fee of 0.2 is deducted from the open PNL of a 2 point move on 2 units
+			positions, _, _ := dealer.ListPositions(context.Background(), nil, nil)
+			assert.True(t, positions[0].PNL.Equal(tt.wantOpenPNL), positions[0].PNL.String())
+
+			_, _, err = dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, tt.giveSide.Opposite(), dec.New(2)))
+			assert.NoError(t, err)
+
+			// Exit fee is 1% of the exit notional
+			roundturns, _, _ := dealer.ListRoundTurns(context.Background(), nil, nil)
+			assert.True(t, roundturns[0].Profit.Equal(tt.wantProfit), roundturns[0].Profit.String())
+		})
+	}
+}
+
+func TestBasketTrade(t *testing.T) {
+	start := time.Now()
+	btc := market.NewAsset("BTCUSD")
+	eth := market.NewAsset("ETHUSD")
+	btcPrices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(100), H: dec.New(110), L: dec.New(90), C: dec.New(100)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(100), H: dec.New(130), L: dec.New(100), C: dec.New(120)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(120), H: dec.New(150), L: dec.New(110), C: dec.New(150)},
+	}
+	ethPrices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(11), L: dec.New(9), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(6), C: dec.New(8)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(8), H: dec.New(9), L: dec.New(4), C: dec.New(5)},
+	}
+
+	dealer := NewDealer()
+	for i := range btcPrices {
+		assert.NoError(t, dealer.ReceiveAssetPrice(context.Background(), btc, btcPrices[i]))
+		assert.NoError(t, dealer.ReceiveAssetPrice(context.Background(), eth, ethPrices[i]))
+		if i == 0 {
+			_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(btc, broker.Buy, dec.New(1)))
+			assert.NoError(t, err)
+			_, _, err = dealer.PlaceOrder(context.Background(), broker.NewOrder(eth, broker.Sell, dec.New(2)))
+			assert.NoError(t, err)
+		}
+	}
+
+	positions, _, _ := dealer.ListPositions(context.Background(), nil, nil)
+	assert.Len(t, positions, 2)
+	assert.True(t, positions[0].MarkPrice.Equal(dec.New(150)))
+	assert.True(t, positions[0].PNL.Equal(dec.New(50)))
+	assert.True(t, positions[1].MarkPrice.Equal(dec.New(5)))
+	assert.True(t, positions[1].PNL.Equal(dec.New(10)))
+
+	balance, _, _ := dealer.GetBalance(context.Background())
+	assert.True(t, balance.Equity.Equal(dec.New(60)))
+
+	_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(eth, broker.Buy, dec.New(2)))
+	assert.NoError(t, err)
+	roundturns, _, _ := dealer.ListRoundTurns(context.Background(), nil, nil)
+	assert.Len(t, roundturns, 1)
+	assert.Equal(t, eth, roundturns[0].Asset)
+	assert.True(t, roundturns[0].Profit.Equal(dec.New(10)))
+}
+
+func TestPartialFillByVolume(t *testing.T) {
+	start := time.Now()
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(12), L: dec.New(8), C: dec.New(10), Volume: 4},
+		{Start: start.Add(1 * time.Hour), O: dec.New(10), H: dec.New(22), L: dec.New(10), C: dec.New(20), Volume: 4},
+		{Start: start.Add(2 * time.Hour), O: dec.New(20), H: dec.New(32), L: dec.New(20), C: dec.New(30), Volume: 20},
+	}
+
+	dealer := NewDealer()
+	dealer.simulator.SetLiquidity(NewVolumeLiquidity(dec.New(0.5)))
+
+	var placed *broker.Order
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		if i == 0 {
+			var err error
+			placed, _, err = dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(5)))
+			assert.NoError(t, err)
+			assert.True(t, placed.State() == broker.OrderOpen)
+			assert.True(t, placed.FilledSize.Equal(dec.New(2)))
+		}
+	}
+
+	order := dealer.simulator.Orders()[0]
+	assert.True(t, order.State() == broker.OrderClosed)
+	assert.True(t, order.FilledSize.Equal(dec.New(5)))
+	assert.True(t, order.FilledPrice.Equal(dec.New(18)))
+
+	positions, _, _ := dealer.ListPositions(context.Background(), nil, nil)
+	assert.Len(t, positions, 1)
+	assert.True(t, positions[0].Size.Equal(dec.New(5)))
+	assert.Equal(t, 3, positions[0].TradeCount)
+	assert.True(t, positions[0].PNL.Equal(dec.New(60)))
+}
+
+func TestPartialFillReopensPosition(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10), Volume: 4},
+		{Start: start.Add(1 * time.Hour), O: dec.New(12), H: dec.New(12), L: dec.New(12), C: dec.New(12), Volume: 4},
+	}
+
+	dealer := NewDealer()
+	dealer.simulator.SetLiquidity(NewVolumeLiquidity(dec.New(0.5)))
+
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		if i == 0 {
+			// The entry fills in part, then the position is closed before the entry has filled in full
+			_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(5)))
+			assert.NoError(t, err)
+			_, _, err = dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Sell, dec.New(2)))
+			assert.NoError(t, err)
+		}
+	}
+
+	positions, _, err := dealer.ListPositions(context.Background(), nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, positions, 2)
+	assert.NotEqual(t, positions[0].ID, positions[1].ID)
+
+	assert.True(t, positions[0].State() == broker.PositionClosed)
+	assert.Equal(t, 2, positions[0].TradeCount)
+	assert.True(t, positions[0].EntryPrice.Equal(dec.New(10)), positions[0].EntryPrice.String())
+
+	assert.True(t, positions[1].State() == broker.PositionOpen)
+	assert.Equal(t, 1, positions[1].TradeCount)
+	assert.True(t, positions[1].Size.Equal(dec.New(2)), positions[1].Size.String())
+	assert.True(t, positions[1].EntryPrice.Equal(dec.New(12)), positions[1].EntryPrice.String())
+
+	fills, _, err := dealer.ListFills(context.Background(), nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, fills, 3)
+	assert.Equal(t, positions[0].ID, fills[0].PositionID)
+	assert.Equal(t, positions[0].ID, fills[1].PositionID)
+	assert.Equal(t, positions[1].ID, fills[2].PositionID)
+}
+
+func TestLongTradeWithStopGap(t *testing.T) {
+	start := time.Now()
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(12), L: dec.New(9), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(10), H: dec.New(11), L: dec.New(9), C: dec.New(10)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(6), H: dec.New(7), L: dec.New(5), C: dec.New(6)},
+	}
+
+	dealer := NewDealer()
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		if i == 0 {
+			_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(2)))
+			assert.NoError(t, err)
+			_, _, err = dealer.PlaceOrder(context.Background(), broker.Order{
+				Side:       broker.Sell,
+				Type:       broker.Stop,
+				StopPrice:  dec.New(8),
+				Size:       dec.New(2),
+				ReduceOnly: true,
+			})
+			assert.NoError(t, err)
+		}
+	}
+
+	// Stop at 8 is filled at the open of 6 as the price gapped through the stop
+	exp := dec.New(-8)
+	act := dealer.simulator.Balance().Trade
+	assert.True(t, act.Equal(exp))
+}
+
+func TestBracketOrderTakeProfitCancelsStop(t *testing.T) {
+	start := time.Now()
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(12), L: dec.New(9), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(10), H: dec.New(16), L: dec.New(9), C: dec.New(14)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(14), H: dec.New(14), L: dec.New(2), C: dec.New(3)},
+	}
+
+	dealer := NewDealer()
+	var placed *broker.BracketOrder
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		if i == 0 {
+			var err error
+			placed, _, err = dealer.PlaceBracketOrder(context.Background(), broker.BracketOrder{
+				Enter:      broker.NewOrder(market.Asset{}, broker.Buy, dec.New(2)),
+				Stop:       broker.Order{Side: broker.Sell, Type: broker.Stop, StopPrice: dec.New(8)},
+				TakeProfit: broker.Order{Side: broker.Sell, Type: broker.Limit, LimitPrice: dec.New(15)},
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, placed.Enter.ID, placed.Stop.ParentID)
+			assert.True(t, placed.Stop.Size.Equal(dec.New(2)))
+		}
+	}
+
+	orders := dealer.simulator.Orders()
+	assert.Len(t, orders, 3)
+	assert.True(t, orders[1].State() == broker.OrderClosed)
+	assert.True(t, orders[1].FilledAt.IsZero())
+	assert.True(t, orders[2].State() == broker.OrderClosed)
+	assert.True(t, orders[2].FilledPrice.Equal(dec.New(15)))
+
+	exp := dec.New(10)
+	act := dealer.simulator.Balance().Trade
+	assert.True(t, act.Equal(exp))
+}
+
+func TestDailyKlineStops(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	prices := []market.Kline{
+		{Start: start.AddDate(0, 0, 0), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)},
+		{Start: start.AddDate(0, 0, 1), O: dec.New(10), H: dec.New(11), L: dec.New(9), C: dec.New(10)},
+		{Start: start.AddDate(0, 0, 2), O: dec.New(9), H: dec.New(9), L: dec.New(5), C: dec.New(6)},
+	}
+
+	tests := []struct {
+		name  string
+		place func(dealer *Dealer) error
+	}{
+		{
+			name: "stop",
+			place: func(dealer *Dealer) error {
+				if _, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(1))); err != nil {
+					return err
+				}
+				_, _, err := dealer.PlaceOrder(context.Background(), broker.Order{Side: broker.Sell, Type: broker.Stop, StopPrice: dec.New(8), Size: dec.New(1)})
+				return err
+			},
+		},
+		{
+			name: "bracket",
+			place: func(dealer *Dealer) error {
+				_, _, err := dealer.PlaceBracketOrder(context.Background(), broker.BracketOrder{
+					Enter: broker.NewOrder(market.Asset{}, broker.Buy, dec.New(1)),
+					Stop:  broker.Order{Side: broker.Sell, Type: broker.Stop, StopPrice: dec.New(8)},
+				})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dealer := NewDealer()
+			for i, price := range prices {
+				assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+				if i == 0 {
+					assert.NoError(t, tt.place(dealer))
+				}
+			}
+
+			// Daily klines share the time of day of the epoch the stop was placed in, but are later epochs
+			orders := dealer.simulator.Orders()
+			assert.Len(t, orders, 2)
+			assert.True(t, orders[1].State() == broker.OrderClosed)
+			assert.True(t, orders[1].FilledPrice.Equal(dec.New(8)), orders[1].FilledPrice.String())
+		})
+	}
+}
+
+func TestBracketOrderLegsHeldUntilEntryFilled(t *testing.T) {
+	start := time.Now()
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(12), L: dec.New(9), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(10), H: dec.New(16), L: dec.New(9), C: dec.New(14)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(14), H: dec.New(16), L: dec.New(14), C: dec.New(15)},
+	}
+
+	dealer := NewDealer()
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		switch i {
+		case 0:
+			_, _, err := dealer.PlaceBracketOrder(context.Background(), broker.BracketOrder{
+				Enter:      broker.Order{Side: broker.Buy, Type: broker.Limit, LimitPrice: dec.New(5), Size: dec.New(1)},
+				TakeProfit: broker.Order{Side: broker.Sell, Type: broker.Limit, LimitPrice: dec.New(15)},
+			})
+			assert.NoError(t, err)
+		case 1:
+			// Take profit is in range but the entry is not filled
+			orders := dealer.simulator.Orders()
+			assert.True(t, orders[1].State() == broker.OrderOpen)
+			_, err := dealer.CancelOrders(context.Background())
+			assert.NoError(t, err)
+		}
+	}
+
+	positions, _, _ := dealer.ListPositions(context.Background(), nil, nil)
+	assert.Len(t, positions, 0)
+}
+
+func TestBracketOrderInvalidLeg(t *testing.T) {
+	dealer := NewDealer()
+	_, _, err := dealer.PlaceBracketOrder(context.Background(), broker.BracketOrder{
+		Enter: broker.NewOrder(market.Asset{}, broker.Buy, dec.New(1)),
+		Stop:  broker.Order{Side: broker.Buy, Type: broker.Stop, StopPrice: dec.New(8)},
+	})
+	assert.ErrorIs(t, err, ErrInvalidOrderState)
+	assert.Len(t, dealer.simulator.Orders(), 0)
+}
+
+func TestBracketOrderRejectedLegAddsNothing(t *testing.T) {
+	dealer := NewDealer()
+	assert.NoError(t, dealer.ReceivePrice(context.Background(), market.Kline{Start: time.Now(), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)}))
+	_, _, err := dealer.PlaceBracketOrder(context.Background(), broker.BracketOrder{
+		Enter:      broker.NewOrder(market.Asset{}, broker.Buy, dec.New(1)),
+		Stop:       broker.Order{Side: broker.Sell, Type: broker.Stop, StopPrice: dec.New(8)},
+		TakeProfit: broker.Order{Side: broker.Sell, Type: broker.Limit, LimitPrice: dec.New(9), TimeInForce: broker.PostOnly},
+	})
+	assert.ErrorIs(t, err, ErrRejectedOrder)
+	assert.Len(t, dealer.simulator.Orders(), 0)
+	assert.Len(t, dealer.simulator.Positions(), 0)
+}
+
+func TestBracketOrderPartialEntryFill(t *testing.T) {
+	start := time.Now()
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10), Volume: 4},
+		{Start: start.Add(1 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(8), C: dec.New(8), Volume: 4},
+		{Start: start.Add(2 * time.Hour), O: dec.New(8), H: dec.New(8), L: dec.New(6), C: dec.New(6), Volume: 10},
+	}
+
+	dealer := NewDealer()
+	dealer.simulator.SetLiquidity(NewVolumeLiquidity(dec.New(0.5)))
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		if i == 0 {
+			_, _, err := dealer.PlaceBracketOrder(context.Background(), broker.BracketOrder{
+				Enter:      broker.Order{Side: broker.Buy, Type: broker.Limit, LimitPrice: dec.New(9), Size: dec.New(4)},
+				Stop:       broker.Order{Side: broker.Sell, Type: broker.Stop, StopPrice: dec.New(7)},
+				TakeProfit: broker.Order{Side: broker.Sell, Type: broker.Limit, LimitPrice: dec.New(15)},
+			})
+			assert.NoError(t, err)
+		}
+	}
+
+	// The entry is half filled, the stop exits the filled size and cancels the remainder of the entry
+	orders := dealer.simulator.Orders()
+	assert.Len(t, orders, 3)
+	assert.True(t, orders[0].State() == broker.OrderClosed)
+	assert.True(t, orders[0].FilledSize.Equal(dec.New(2)), orders[0].FilledSize.String())
+	assert.True(t, orders[1].Size.Equal(dec.New(2)), orders[1].Size.String())
+	assert.True(t, orders[1].FilledSize.Equal(dec.New(2)), orders[1].FilledSize.String())
+	assert.True(t, orders[2].State() == broker.OrderClosed)
+	assert.True(t, orders[2].FilledSize.IsZero())
+
+	positions := dealer.simulator.Positions()
+	assert.Len(t, positions, 1)
+	assert.True(t, positions[0].State() == broker.PositionClosed)
+}
+
+func TestLongTradeWithTrailingStop(t *testing.T) {
+	start := time.Now()
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(12), L: dec.New(9), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(13), H: dec.New(15), L: dec.New(13), C: dec.New(14)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(18), H: dec.New(20), L: dec.New(18), C: dec.New(19)},
+		{Start: start.Add(3 * time.Hour), O: dec.New(19), H: dec.New(19), L: dec.New(12), C: dec.New(13)},
+	}
+
+	dealer := NewDealer()
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		if i == 0 {
+			_, _, err := dealer.PlaceBracketOrder(context.Background(), broker.BracketOrder{
+				Enter: broker.NewOrder(market.Asset{}, broker.Buy, dec.New(1)),
+				Stop:  broker.Order{Side: broker.Sell, Type: broker.TrailingStop, TrailAmount: dec.New(3)},
+			})
+			assert.NoError(t, err)
+		}
+	}
+
+	// Stop trails the high of 20 and fills at 17
+	exp := dec.New(7)
+	act := dealer.simulator.Balance().Trade
+	assert.True(t, act.Equal(exp))
+}
+
+func TestTimeInForce(t *testing.T) {
+	start := time.Now()
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(12), L: dec.New(9), C: dec.New(10), Volume: 4},
+		{Start: start.Add(1 * time.Hour), O: dec.New(10), H: dec.New(12), L: dec.New(8), C: dec.New(10), Volume: 4},
+		{Start: start.Add(2 * time.Hour), O: dec.New(10), H: dec.New(12), L: dec.New(7), C: dec.New(10), Volume: 4},
+	}
+
+	tests := []struct {
+		name       string
+		give       broker.Order
+		wantErr    error
+		wantState  broker.OrderState
+		wantFilled decimal.Decimal
+	}{
+		{
+			name:       "GTC limit fills in later epoch",
+			give:       broker.Order{Side: broker.Buy, Type: broker.Limit, LimitPrice: dec.New(8), Size: dec.New(1), TimeInForce: broker.GTC},
+			wantState:  broker.OrderClosed,
+			wantFilled: dec.New(1),
+		},
+		{
+			name:       "IOC limit not marketable is cancelled",
+			give:       broker.Order{Side: broker.Buy, Type: broker.Limit, LimitPrice: dec.New(8), Size: dec.New(1), TimeInForce: broker.IOC},
+			wantState:  broker.OrderClosed,
+			wantFilled: decimal.Zero,
+		},
+		{
+			name:       "IOC market partially fills and cancels remainder",
+			give:       broker.Order{Side: broker.Buy, Type: broker.Market, Size: dec.New(3), TimeInForce: broker.IOC},
+			wantState:  broker.OrderClosed,
+			wantFilled: dec.New(2),
+		},
+		{
+			name:       "FOK market cannot fill in full",
+			give:       broker.Order{Side: broker.Buy, Type: broker.Market, Size: dec.New(3), TimeInForce: broker.FOK},
+			wantState:  broker.OrderClosed,
+			wantFilled: decimal.Zero,
+		},
+		{
+			name:       "FOK marketable limit fills at close",
+			give:       broker.Order{Side: broker.Buy, Type: broker.Limit, LimitPrice: dec.New(11), Size: dec.New(2), TimeInForce: broker.FOK},
+			wantState:  broker.OrderClosed,
+			wantFilled: dec.New(2),
+		},
+		{
+			name:       "GTD expires before fill",
+			give:       broker.Order{Side: broker.Buy, Type: broker.Limit, LimitPrice: dec.New(7), Size: dec.New(1), TimeInForce: broker.GTD, ExpiresAt: start.Add(90 * time.Minute)},
+			wantState:  broker.OrderClosed,
+			wantFilled: decimal.Zero,
+		},
+		{
+			name:    "post-only marketable limit is rejected",
+			give:    broker.Order{Side: broker.Sell, Type: broker.Limit, LimitPrice: dec.New(9), Size: dec.New(1), TimeInForce: broker.PostOnly},
+			wantErr: ErrRejectedOrder,
+		},
+		{
+			name:    "GTD without expiry is invalid",
+			give:    broker.Order{Side: broker.Buy, Type: broker.Limit, LimitPrice: dec.New(7), Size: dec.New(1), TimeInForce: broker.GTD},
+			wantErr: ErrInvalidOrderState,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dealer := NewDealer()
+			dealer.simulator.SetLiquidity(NewVolumeLiquidity(dec.New(0.5)))
+			for i, price := range prices {
+				assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+				if i == 0 {
+					_, _, err := dealer.PlaceOrder(context.Background(), tt.give)
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			order := dealer.simulator.Orders()[0]
+			assert.Equal(t, tt.wantState, order.State())
+			assert.True(t, order.FilledSize.Equal(tt.wantFilled))
+		})
+	}
+}
+
+func TestFillLiquidity(t *testing.T) {
+	start := time.Now()
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(10), H: dec.New(12), L: dec.New(9), C: dec.New(11)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(11), H: dec.New(11), L: dec.New(8), C: dec.New(9)},
+	}
+
+	tests := []struct {
+		name    string
+		give    broker.Order
+		want    broker.Liquidity
+		wantFee decimal.Decimal
+	}{
+		{
+			name:    "resting limit is maker",
+			give:    broker.Order{Side: broker.Buy, Type: broker.Limit, LimitPrice: dec.New(9), Size: dec.New(1)},
+			want:    broker.Maker,
+			wantFee: dec.New(0.009),
+		},
+		{
+			name:    "marketable IOC limit filled at close is taker",
+			give:    broker.Order{Side: broker.Buy, Type: broker.Limit, LimitPrice: dec.New(11), Size: dec.New(1), TimeInForce: broker.IOC},
+			want:    broker.Taker,
+			wantFee: dec.New(0.02),
+		},
+		{
+			name:    "marketable FOK limit filled at close is taker",
+			give:    broker.Order{Side: broker.Buy, Type: broker.Limit, LimitPrice: dec.New(11), Size: dec.New(1), TimeInForce: broker.FOK},
+			want:    broker.Taker,
+			wantFee: dec.New(0.02),
+		},
+		{
+			name:    "stop limit filled when triggered is taker",
+			give:    broker.Order{Side: broker.Buy, Type: broker.StopLimit, StopPrice: dec.New(11), LimitPrice: dec.New(11.5), Size: dec.New(1)},
+			want:    broker.Taker,
+			wantFee: dec.New(0.023),
+		},
+		{
+			name:    "stop limit resting after trigger is maker",
+			give:    broker.Order{Side: broker.Buy, Type: broker.StopLimit, StopPrice: dec.New(11), LimitPrice: dec.New(8.5), Size: dec.New(1)},
+			want:    broker.Maker,
+			wantFee: dec.New(0.0085),
+		},
+		{
+			name:    "market is taker",
+			give:    broker.NewOrder(market.Asset{}, broker.Buy, dec.New(1)),
+			want:    broker.Taker,
+			wantFee: dec.New(0.02),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dealer := NewDealerWithCost(NewTieredFeeCoster(FeeTier{MakerPct: dec.New(0.001), TakerPct: dec.New(0.002)}))
+			for i, price := range prices {
+				assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+				if i == 0 {
+					_, _, err := dealer.PlaceOrder(context.Background(), tt.give)
+					assert.NoError(t, err)
+				}
+			}
+			fills := dealer.simulator.Fills()
+			assert.Len(t, fills, 1)
+			assert.Equal(t, tt.want, fills[0].Liquidity)
+			assert.True(t, fills[0].Fee.Equal(tt.wantFee), fills[0].Fee.String())
+		})
+	}
+}
+
+func TestRejectedFillHasNoSideEffects(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	book := market.NewOrderBook(market.DepthUpdate{
+		Bids: []market.BookLevel{{Price: dec.New(99), Size: dec.New(2)}},
+		Asks: []market.BookLevel{{Price: dec.New(101), Size: dec.New(2)}},
+	})
+
+	cost := NewTieredFeeCoster(FeeTier{TakerPct: dec.New(0.001)}, FeeTier{MinNotional: dec.New(50), TakerPct: dec.New(0.0005)})
+	dealer := NewDealerWithCost(cost)
+	assert.NoError(t, dealer.ReceivePrice(context.Background(), market.Kline{Start: start, O: dec.New(100), H: dec.New(100), L: dec.New(100), C: dec.New(100)}))
+	assert.NoError(t, dealer.ReceiveOrderBook(context.Background(), market.Asset{}, book))
+
+	// A reduce-only order without a position is rejected before it takes depth or adds to the traded notional
+	order := broker.NewOrder(market.Asset{}, broker.Sell, dec.New(1))
+	order.ReduceOnly = true
+	_, _, err := dealer.PlaceOrder(context.Background(), order)
+	assert.ErrorIs(t, err, ErrRejectedOrder)
+
+	assert.True(t, cost.RollingNotional(start.Add(time.Hour)).IsZero())
+	assert.True(t, cost.Tier(start.Add(time.Hour)).TakerPct.Equal(dec.New(0.001)))
+	bid, _ := dealer.simulator.books[market.Asset{}].BestBid()
+	assert.True(t, bid.Size.Equal(dec.New(2)), bid.Size.String())
+}
+
+func TestOrderManagement(t *testing.T) {
+	start := time.Now()
+	btc := market.NewAsset("BTCUSD")
+	eth := market.NewAsset("ETHUSD")
+	price := market.Kline{Start: start, O: dec.New(10), H: dec.New(12), L: dec.New(9), C: dec.New(10)}
+
+	dealer := NewDealer()
+	ctx := context.Background()
+	assert.NoError(t, dealer.ReceivePrice(ctx, price))
+
+	enter, _, err := dealer.PlaceOrder(ctx, broker.NewOrder(btc, broker.Buy, dec.New(2)))
+	assert.NoError(t, err)
+	stop, _, err := dealer.PlaceOrder(ctx, broker.Order{Asset: btc, Side: broker.Sell, Type: broker.Stop, StopPrice: dec.New(8), Size: dec.New(2), ReduceOnly: true})
+	assert.NoError(t, err)
+	limit, _, err := dealer.PlaceOrder(ctx, broker.Order{Asset: eth, Side: broker.Buy, Type: broker.Limit, LimitPrice: dec.New(5), Size: dec.New(1)})
+	assert.NoError(t, err)
+
+	t.Run("list with filter", func(t *testing.T) {
+		orders, _, err := dealer.ListOrders(ctx, &broker.OrderFilter{Asset: btc, States: []broker.OrderState{broker.OrderOpen}}, nil)
+		assert.NoError(t, err)
+		assert.Len(t, orders, 1)
+		assert.Equal(t, stop.ID, orders[0].ID)
+
+		orders, _, err = dealer.ListOrders(ctx, nil, nil)
+		assert.NoError(t, err)
+		assert.Len(t, orders, 3)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		act, _, err := dealer.GetOrder(ctx, enter.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, *enter, *act)
+
+		_, _, err = dealer.GetOrder(ctx, "unknown")
+		assert.ErrorIs(t, err, broker.ErrNotFound)
+	})
+
+	t.Run("amend", func(t *testing.T) {
+		act, _, err := dealer.AmendOrder(ctx, stop.ID, broker.AmendOpts{StopPrice: dec.New(9)})
+		assert.NoError(t, err)
+		assert.True(t, act.StopPrice.Equal(dec.New(9)))
+		assert.True(t, act.Size.Equal(dec.New(2)))
+
+		_, _, err = dealer.AmendOrder(ctx, enter.ID, broker.AmendOpts{Size: dec.New(1)})
+		assert.ErrorIs(t, err, ErrInvalidOrderState)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		act, _, err := dealer.CancelOrder(ctx, limit.ID)
+		assert.NoError(t, err)
+		assert.True(t, act.State() == broker.OrderClosed)
+
+		_, _, err = dealer.CancelOrder(ctx, limit.ID)
+		assert.ErrorIs(t, err, ErrInvalidOrderState)
+
+		orders, _, _ := dealer.ListOrders(ctx, &broker.OrderFilter{States: []broker.OrderState{broker.OrderOpen}}, nil)
+		assert.Len(t, orders, 1)
+	})
+}
+
+func TestMarginRejectAndLiquidate(t *testing.T) {
+	start := time.Now()
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(100), H: dec.New(100), L: dec.New(100), C: dec.New(100)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(100), H: dec.New(102), L: dec.New(95), C: dec.New(96)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(96), H: dec.New(96), L: dec.New(80), C: dec.New(85)},
+	}
+
+	dealer := NewDealer()
+	dealer.SetInitialCapital(dec.New(100))
+	dealer.simulator.SetMargin(NewMargin(MarginRule{
+		MaxLeverage:          dec.New(10),
+		MaintenanceMarginPct: dec.New(0.05),
+		LiquidationFeePct:    dec.New(0.01),
+	}))
+	var events []Event
+	dealer.Subscribe(SubscriberFunc(func(event Event) {
+		events = append(events, event)
+	}))
+
+	ctx := context.Background()
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(ctx, price))
+		if i == 0 {
+			// 11x leverage exceeds max leverage
+			_, _, err := dealer.PlaceOrder(ctx, broker.NewOrder(market.Asset{}, broker.Buy, dec.New(11)))
+			assert.ErrorIs(t, err, ErrRejectedOrder)
+
+			_, _, err = dealer.PlaceOrder(ctx, broker.NewOrder(market.Asset{}, broker.Buy, dec.New(10)))
+			assert.NoError(t, err)
+
+			balance, _, _ := dealer.GetBalance(ctx)
+			assert.True(t, balance.MarginUsed.Equal(dec.New(100)))
+			assert.True(t, balance.AvailableMargin.IsZero())
+		}
+	}
+
+	// Liquidation price is (1000 - 100) / (10 * 0.95) = 94.7368...
+	roundturns, _, _ := dealer.ListRoundTurns(ctx, nil, nil)
+	assert.Len(t, roundturns, 1)
+
+	orders := dealer.simulator.Orders()
+	liquidation := orders[len(orders)-1]
+	assert.True(t, liquidation.FilledPrice.Round(4).Equal(dec.New(94.7368)))
+	assert.True(t, liquidation.Fee.Round(4).Equal(dec.New(9.4737)))
+
+	balance, _, _ := dealer.GetBalance(ctx)
+	assert.True(t, balance.Trade.Round(4).Equal(dec.New(37.8947)))
+	assert.True(t, balance.Equity.Equal(balance.Trade))
+
+	// The liquidation is journaled as an order fill closing the position followed by the liquidation
+	var types []EventType
+	for _, event := range events[len(events)-5:] {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []EventType{OrderOpened, PositionClosed, OrderFilled, PositionLiquidated, EquityMarked}, types)
+	filled := events[len(events)-3]
+	assert.Equal(t, liquidation.ID, filled.Order.ID)
+	assert.True(t, filled.Order.FilledSize.Equal(dec.New(10)))
+	liquidated := events[len(events)-2]
+	assert.True(t, liquidated.Position.State() == broker.PositionClosed)
+	assert.True(t, liquidated.Amount.Equal(liquidation.Fee))
+}
+
+func TestMarginReservedByRestingOrders(t *testing.T) {
+	start := time.Now()
+	dealer := NewDealer()
+	dealer.SetInitialCapital(dec.New(100))
+	dealer.simulator.SetMargin(NewMargin(MarginRule{MaxLeverage: dec.New(10)}))
+
+	ctx := context.Background()
+	assert.NoError(t, dealer.ReceivePrice(ctx, market.Kline{Start: start, O: dec.New(100), H: dec.New(100), L: dec.New(100), C: dec.New(100)}))
+
+	limit := func(side broker.OrderSide, size float64) broker.Order {
+		return broker.Order{Side: side, Type: broker.Limit, LimitPrice: dec.New(90), Size: dec.New(size)}
+	}
+	first, _, err := dealer.PlaceOrder(ctx, limit(broker.Buy, 6))
+	assert.NoError(t, err)
+
+	// The resting order reserves 6 * 90 / 10 = 54 margin
+	balance, _, _ := dealer.GetBalance(ctx)
+	assert.True(t, balance.MarginUsed.Equal(dec.New(54)), balance.MarginUsed.String())
+	assert.True(t, balance.AvailableMargin.Equal(dec.New(46)), balance.AvailableMargin.String())
+
+	_, _, err = dealer.PlaceOrder(ctx, limit(broker.Buy, 6))
+	assert.ErrorIs(t, err, ErrRejectedOrder)
+	_, _, err = dealer.PlaceOrder(ctx, limit(broker.Sell, 6))
+	assert.ErrorIs(t, err, ErrRejectedOrder)
+
+	// Cancelling releases the reserved margin
+	_, _, err = dealer.CancelOrder(ctx, first.ID)
+	assert.NoError(t, err)
+	_, _, err = dealer.PlaceOrder(ctx, limit(broker.Buy, 6))
+	assert.NoError(t, err)
+}
+
+func TestLiquidationAfterFillInKline(t *testing.T) {
+	start := time.Now()
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(100), H: dec.New(100), L: dec.New(100), C: dec.New(100)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(100), H: dec.New(102), L: dec.New(85), C: dec.New(100)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(100), H: dec.New(100), L: dec.New(90), C: dec.New(92)},
+	}
+
+	dealer := NewDealer()
+	dealer.SetInitialCapital(dec.New(100))
+	dealer.simulator.SetMargin(NewMargin(MarginRule{MaxLeverage: dec.New(10), MaintenanceMarginPct: dec.New(0.05)}))
+	dealer.simulator.SetIntrabarPath(PathOLHC)
+
+	ctx := context.Background()
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(ctx, price))
+		switch i {
+		case 0:
+			_, _, err := dealer.PlaceOrder(ctx, broker.Order{Side: broker.Buy, Type: broker.Stop, StopPrice: dec.New(101), Size: dec.New(9)})
+			assert.NoError(t, err)
+		case 1:
+			// Liquidation price is (909 - 100) / (9 * 0.95) = 94.6198...
+			// the low of 85 is traded before the stop fills at 101 so does not liquidate the position
+			positions := dealer.simulator.Positions()
+			assert.Len(t, positions, 1)
+			assert.True(t, positions[0].State() == broker.PositionOpen)
+		}
+	}
+
+	positions := dealer.simulator.Positions()
+	assert.True(t, positions[0].State() == broker.PositionClosed)
+	assert.True(t, positions[0].ExitPrice.Round(4).Equal(dec.New(94.6199)), positions[0].ExitPrice.String())
+}
+
+func TestBracketOrderIntrabarPath(t *testing.T) {
+	start := time.Now()
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(12), L: dec.New(9), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(10), H: dec.New(16), L: dec.New(7), C: dec.New(12)},
+	}
+
+	tests := []struct {
+		name     string
+		givePath IntrabarPath
+		want     decimal.Decimal
+	}{
+		{
+			name:     "placement sequence",
+			givePath: nil,
+			want:     dec.New(-4),
+		},
+		{
+			name:     "ohlc takes profit",
+			givePath: PathOHLC,
+			want:     dec.New(10),
+		},
+		{
+			name:     "olhc stops out",
+			givePath: PathOLHC,
+			want:     dec.New(-4),
+		},
+		{
+			name:     "pessimistic stops out",
+			givePath: PathPessimistic,
+			want:     dec.New(-4),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dealer := NewDealer()
+			dealer.simulator.SetIntrabarPath(tt.givePath)
+			for i, price := range prices {
+				assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+				if i == 0 {
+					_, _, err := dealer.PlaceBracketOrder(context.Background(), broker.BracketOrder{
+						Enter:      broker.NewOrder(market.Asset{}, broker.Buy, dec.New(2)),
+						Stop:       broker.Order{Side: broker.Sell, Type: broker.Stop, StopPrice: dec.New(8)},
+						TakeProfit: broker.Order{Side: broker.Sell, Type: broker.Limit, LimitPrice: dec.New(15)},
+					})
+					assert.NoError(t, err)
+				}
+			}
+			act := dealer.simulator.Balance().Trade
+			assert.True(t, act.Equal(tt.want), act.String())
+		})
+	}
+}
+
+func TestBracketOrderLowerTimeframe(t *testing.T) {
+	start := time.Now().Truncate(time.Hour)
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(12), L: dec.New(9), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(10), H: dec.New(16), L: dec.New(7), C: dec.New(12)},
+	}
+
+	tests := []struct {
+		name      string
+		giveFeed  []market.Kline
+		wantTrade decimal.Decimal
+	}{
+		{
+			name:      "no lower timeframe",
+			wantTrade: dec.New(-4),
+		},
+		{
+			name: "high before low",
+			giveFeed: []market.Kline{
+				{Start: start.Add(2 * time.Hour), O: dec.New(10), H: dec.New(16), L: dec.New(10), C: dec.New(15)},
+				{Start: start.Add(150 * time.Minute), O: dec.New(15), H: dec.New(15), L: dec.New(7), C: dec.New(12)},
+			},
+			wantTrade: dec.New(10),
+		},
+		{
+			name: "low before high",
+			giveFeed: []market.Kline{
+				{Start: start.Add(2 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(7), C: dec.New(8)},
+				{Start: start.Add(150 * time.Minute), O: dec.New(8), H: dec.New(16), L: dec.New(8), C: dec.New(12)},
+			},
+			wantTrade: dec.New(-4),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dealer := NewDealer()
+			if tt.giveFeed != nil {
+				dealer.SetLowerTimeframe(market.Asset{}, NewLowerTimeframe(tt.giveFeed, time.Hour))
+			}
+			for i, price := range prices {
+				assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+				if i == 0 {
+					_, _, err := dealer.PlaceBracketOrder(context.Background(), broker.BracketOrder{
+						Enter:      broker.NewOrder(market.Asset{}, broker.Buy, dec.New(2)),
+						Stop:       broker.Order{Side: broker.Sell, Type: broker.Stop, StopPrice: dec.New(8)},
+						TakeProfit: broker.Order{Side: broker.Sell, Type: broker.Limit, LimitPrice: dec.New(15)},
+					})
+					assert.NoError(t, err)
+				}
+			}
+			act := dealer.simulator.Balance().Trade
+			assert.True(t, act.Equal(tt.wantTrade), act.String())
+			// Equity is marked to the close of the received kline
+			assert.True(t, dealer.simulator.Balance().Equity.Equal(tt.wantTrade))
+		})
+	}
+}
+
+func TestTradeByTrade(t *testing.T) {
+	start := time.Now()
+	trade := func(ms int, price, size float64, side market.TradeSide) market.Trade {
+		return market.Trade{Time: start.Add(time.Duration(ms) * time.Millisecond), Price: dec.New(price), Size: dec.New(size), Side: side}
+	}
+
+	dealer := NewDealer()
+	assert.NoError(t, dealer.ReceiveTrade(context.Background(), market.Asset{}, trade(0, 101, 1, market.TakerBuy)))
+	order, _, err := dealer.PlaceOrder(context.Background(), broker.Order{
+		Side: broker.Buy, Type: broker.Limit, LimitPrice: dec.New(100), Size: dec.New(2),
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		give       market.Trade
+		wantFilled decimal.Decimal
+	}{
+		{
+			name:       "price above limit",
+			give:       trade(10, 100.5, 1, market.TakerSell),
+			wantFilled: dec.New(0),
+		},
+		{
+			name:       "aggressor on same side",
+			give:       trade(20, 100, 1, market.TakerBuy),
+			wantFilled: dec.New(0),
+		},
+		{
+			name:       "partial fill capped at print size",
+			give:       trade(30, 100, 0.5, market.TakerSell),
+			wantFilled: dec.New(0.5),
+		},
+		{
+			name:       "fill remainder through limit",
+			give:       trade(40, 99, 5, market.TakerSell),
+			wantFilled: dec.New(2),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, dealer.ReceiveTrade(context.Background(), market.Asset{}, tt.give))
+			act, _, err := dealer.GetOrder(context.Background(), order.ID)
+			assert.NoError(t, err)
+			assert.True(t, act.FilledSize.Equal(tt.wantFilled), act.FilledSize.String())
+		})
+	}
+
+	order, _, _ = dealer.GetOrder(context.Background(), order.ID)
+	assert.True(t, order.State() == broker.OrderClosed)
+	assert.True(t, order.FilledPrice.Equal(dec.New(100)))
+}
+
+func TestMarketOrderWalksOrderBook(t *testing.T) {
+	start := time.Now()
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(100), H: dec.New(100), L: dec.New(100), C: dec.New(100)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(100), H: dec.New(100), L: dec.New(100), C: dec.New(100)},
+	}
+	book := market.NewOrderBook(market.DepthUpdate{
+		Bids: []market.BookLevel{{Price: dec.New(99), Size: dec.New(1)}},
+		Asks: []market.BookLevel{
+			{Price: dec.New(101), Size: dec.New(1)},
+			{Price: dec.New(103), Size: dec.New(1)},
+		},
+	})
+
+	dealer := NewDealer()
+	assert.NoError(t, dealer.ReceivePrice(context.Background(), prices[0]))
+	assert.NoError(t, dealer.ReceiveOrderBook(context.Background(), market.Asset{}, book))
+
+	// Fill across both ask levels and cap at the depth of the book
+	order, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(3)))
+	assert.NoError(t, err)
+	assert.True(t, order.FilledPrice.Equal(dec.New(102)), order.FilledPrice.String())
+	assert.True(t, order.FilledSize.Equal(dec.New(2)))
+	assert.True(t, order.State() == broker.OrderOpen)
+
+	// Depth taken by the fill is not reused, the remainder fills against the next book
+	assert.NoError(t, dealer.ReceivePrice(context.Background(), prices[1]))
+	order, _, _ = dealer.GetOrder(context.Background(), order.ID)
+	assert.True(t, order.FilledSize.Equal(dec.New(2)))
+
+	assert.NoError(t, dealer.ReceiveOrderBook(context.Background(), market.Asset{}, book))
+	assert.NoError(t, dealer.ReceivePrice(context.Background(), prices[1]))
+	order, _, _ = dealer.GetOrder(context.Background(), order.ID)
+	assert.True(t, order.FilledSize.Equal(dec.New(3)))
+	assert.True(t, order.FilledPrice.Equal(dec.New(305).Div(dec.New(3))), order.FilledPrice.String())
+	assert.True(t, order.State() == broker.OrderClosed)
+
+	// Caller book is not modified by fills
+	assert.Len(t, book.Asks, 2)
+}
+
+func TestMarketOrderWithLatency(t *testing.T) {
+	start := time.Now()
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(12), L: dec.New(9), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(11), H: dec.New(16), L: dec.New(9), C: dec.New(14)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(14), H: dec.New(15), L: dec.New(12), C: dec.New(13)},
+	}
+
+	dealer := NewDealer()
+	dealer.simulator.SetLatency(NewFixedLatency(200 * time.Millisecond))
+
+	var enter, cancel *broker.Order
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		if i == 0 {
+			var err error
+			enter, _, err = dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(1)))
+			assert.NoError(t, err)
+			assert.True(t, enter.State() == broker.OrderPending)
+			assert.NotEmpty(t, enter.ID)
+
+			// Orders in flight can be cancelled before they become live
+			cancel, _, err = dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(1)))
+			assert.NoError(t, err)
+			_, _, err = dealer.CancelOrder(context.Background(), cancel.ID)
+			assert.NoError(t, err)
+		}
+	}
+
+	enter, _, _ = dealer.GetOrder(context.Background(), enter.ID)
+	assert.True(t, enter.State() == broker.OrderClosed)
+	assert.True(t, enter.FilledPrice.Equal(dec.New(11)), enter.FilledPrice.String())
+	assert.Equal(t, start.Add(201*time.Millisecond), enter.OpenedAt)
+
+	cancel, _, _ = dealer.GetOrder(context.Background(), cancel.ID)
+	assert.True(t, cancel.FilledAt.IsZero())
+
+	positions, _, _ := dealer.ListPositions(context.Background(), nil, nil)
+	assert.Len(t, positions, 1)
+}
+
+func TestSimulatorEvents(t *testing.T) {
+	start := time.Now()
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(12), L: dec.New(9), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(10), H: dec.New(16), L: dec.New(9), C: dec.New(14)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(14), H: dec.New(14), L: dec.New(12), C: dec.New(13)},
+	}
+
+	var events []EventType
+	var rejected Event
+	dealer := NewDealer()
+	dealer.Subscribe(SubscriberFunc(func(event Event) {
+		events = append(events, event.Type)
+		if event.Type == OrderRejected {
+			rejected = event
+		}
+	}))
+
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		switch i {
+		case 0:
+			_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(2)))
+			assert.NoError(t, err)
+			_, _, err = dealer.PlaceOrder(context.Background(), broker.Order{Side: broker.Sell, Type: broker.Limit, LimitPrice: dec.New(20), Size: dec.New(2)})
+			assert.NoError(t, err)
+		case 1:
+			_, err := dealer.CancelOrders(context.Background())
+			assert.NoError(t, err)
+			_, _, err = dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Sell, dec.New(3)))
+			var rejectErr *RejectedOrderError
+			assert.ErrorAs(t, err, &rejectErr)
+			assert.ErrorIs(t, err, ErrRejectedOrder)
+			_, _, err = dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Sell, dec.New(2)))
+			assert.NoError(t, err)
+		}
+	}
+
+	exp := []EventType{
+		EquityMarked,
+		OrderOpened, PositionOpened, OrderFilled,
+		OrderOpened,
+		EquityMarked,
+		OrderCancelled,
+		OrderOpened, OrderRejected,
+		OrderOpened, PositionClosed, OrderFilled,
+		EquityMarked,
+	}
+	assert.Equal(t, exp, events)
+	assert.Equal(t, "order size exceeds position size", rejected.Reason)
+	assert.True(t, rejected.Order.FilledSize.Equal(dec.New(3)))
+}
+
+func TestMultiCurrencyBalance(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	btceur, eurusdt := market.NewAsset("BTCEUR"), market.NewAsset("EURUSDT")
+	converter := market.NewConverter()
+	converter.SetPair(btceur, market.CurrencyPair{Base: "BTC", Quote: "EUR"})
+	converter.SetPair(eurusdt, market.CurrencyPair{Base: "EUR", Quote: "USDT"})
+
+	sim := NewSimulatorWithCost(&PerpCoster{TransactionPct: dec.New(0.01)})
+	sim.SetInitialCapital(dec.New(1000))
+	sim.SetCurrency("USDT", converter)
+
+	// Orders are rejected until the quote currency can be converted to the base currency
+	assert.NoError(t, sim.NextAsset(btceur, market.Kline{Start: start, O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)}))
+	_, err := sim.AddOrder(broker.NewOrder(btceur, broker.Buy, dec.New(1)))
+	assert.ErrorIs(t, err, ErrRejectedOrder)
+
+	assert.NoError(t, sim.NextAsset(eurusdt, market.Kline{Start: start, O: dec.New(1.2), H: dec.New(1.2), L: dec.New(1.2), C: dec.New(1.2)}))
+	_, err = sim.AddOrder(broker.NewOrder(btceur, broker.Buy, dec.New(1)))
+	assert.NoError(t, err)
+
+	// Unrealized PNL net of the fee is held in EUR and converted at the latest rate
+	start = start.Add(time.Hour)
+	assert.NoError(t, sim.NextAsset(eurusdt, market.Kline{Start: start, O: dec.New(1.5), H: dec.New(1.5), L: dec.New(1.5), C: dec.New(1.5)}))
+	assert.NoError(t, sim.NextAsset(btceur, market.Kline{Start: start, O: dec.New(20), H: dec.New(20), L: dec.New(20), C: dec.New(20)}))
+	balance := sim.Balance()
+	assert.Equal(t, "USDT", balance.Currency)
+	assert.True(t, balance.Trade.Equal(dec.New(1000)), balance.Trade.String())
+	assert.True(t, balance.Equity.Equal(dec.New(1014.85)), balance.Equity.String())
+	assert.Len(t, balance.Currencies, 2)
+	assert.Equal(t, "EUR", balance.Currencies[0].Currency)
+	assert.True(t, balance.Currencies[0].Trade.IsZero())
+	assert.True(t, balance.Currencies[0].Equity.Equal(dec.New(9.9)), balance.Currencies[0].Equity.String())
+
+	// Realized profit net of fees is credited in EUR
+	_, err = sim.AddOrder(broker.NewOrder(btceur, broker.Sell, dec.New(1)))
+	assert.NoError(t, err)
+	balance = sim.Balance()
+	assert.True(t, balance.Trade.Equal(dec.New(1014.55)), balance.Trade.String())
+	assert.True(t, balance.Currencies[0].Trade.Equal(dec.New(9.7)), balance.Currencies[0].Trade.String())
+	assert.True(t, balance.Currencies[1].Trade.Equal(dec.New(1000)), balance.Currencies[1].Trade.String())
+}
+
+func TestMultiCurrencyDefaultFeed(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	btceur, eurusdt := market.NewAsset("BTCEUR"), market.NewAsset("EURUSDT")
+	converter := market.NewConverter()
+	converter.SetPair(btceur, market.CurrencyPair{Base: "BTC", Quote: "EUR"})
+	converter.SetPair(eurusdt, market.CurrencyPair{Base: "EUR", Quote: "USDT"})
+
+	sim := NewSimulator()
+	sim.SetInitialCapital(dec.New(1000))
+	sim.SetCurrency("USDT", converter)
+
+	// BTCEUR has no feed of its own so its rate is updated from the default feed
+	assert.NoError(t, sim.NextAsset(eurusdt, market.Kline{Start: start, O: dec.New(1.2), H: dec.New(1.2), L: dec.New(1.2), C: dec.New(1.2)}))
+	assert.NoError(t, sim.Next(market.Kline{Start: start, O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)}))
+	_, err := sim.AddOrder(broker.NewOrder(btceur, broker.Buy, dec.New(1)))
+	assert.NoError(t, err)
+
+	start = start.Add(time.Hour)
+	assert.NoError(t, sim.Next(market.Kline{Start: start, O: dec.New(20), H: dec.New(20), L: dec.New(20), C: dec.New(20)}))
+	rates := converter.Rates()
+	assert.Len(t, rates, 2)
+	assert.Equal(t, "BTC", rates[0].Pair.Base)
+	assert.True(t, rates[0].Rate.Equal(dec.New(20)), rates[0].Rate.String())
+	assert.True(t, rates[1].Rate.Equal(dec.New(1.2)), rates[1].Rate.String())
+
+	// EURUSDT has a feed of its own and is not updated from the default feed
+	balance := sim.Balance()
+	assert.True(t, balance.Equity.Equal(dec.New(1012)), balance.Equity.String())
+}
+
+func TestHedgeModePositions(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(12), H: dec.New(12), L: dec.New(12), C: dec.New(12)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(11), H: dec.New(11), L: dec.New(11), C: dec.New(11)},
+	}
+
+	dealer := NewDealer()
+	dealer.SetInitialCapital(dec.New(1000))
+	dealer.SetPositionMode(Hedge)
+
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		switch i {
+		case 0:
+			// A buy without a short position to reduce cannot open one
+			_, _, err := dealer.PlaceOrder(context.Background(), broker.Order{Side: broker.Buy, Type: broker.Market, Size: dec.New(1), PositionSide: broker.Sell})
+			assert.ErrorIs(t, err, ErrRejectedOrder)
+
+			// Long and short positions are opened independently
+			_, _, err = dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(2)))
+			assert.NoError(t, err)
+			_, _, err = dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Sell, dec.New(1)))
+			assert.NoError(t, err)
+		case 1:
+			// Close the long, the short remains open
+			order, _, err := dealer.PlaceOrder(context.Background(), broker.Order{Side: broker.Sell, Type: broker.Market, Size: dec.New(2), ReduceOnly: true})
+			assert.NoError(t, err)
+			assert.Equal(t, broker.Buy, order.PositionSide)
+		}
+	}
+
+	positions := dealer.simulator.Positions()
+	assert.Len(t, positions, 2)
+	assert.Equal(t, broker.Buy, positions[0].Side)
+	assert.True(t, positions[0].State() == broker.PositionClosed)
+	assert.Equal(t, broker.Sell, positions[1].Side)
+	assert.True(t, positions[1].State() == broker.PositionOpen)
+	assert.True(t, positions[1].PNL.Equal(dec.New(-1)), positions[1].PNL.String())
+
+	// Long realized 4, short unrealized -1
+	balance := dealer.simulator.Balance()
+	assert.True(t, balance.Trade.Equal(dec.New(1004)), balance.Trade.String())
+	assert.True(t, balance.Equity.Equal(dec.New(1003)), balance.Equity.String())
+}
+
+func TestHedgeModeFunding(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)},
+		{Start: start.Add(3 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)},
+	}
+
+	dealer := NewDealerWithCost(&PerpCoster{FundingHourPct: dec.New(0.01)})
+	dealer.SetInitialCapital(dec.New(1000))
+	dealer.SetPositionMode(Hedge)
+
+	funding := make(map[broker.OrderSide]decimal.Decimal)
+	dealer.Subscribe(SubscriberFunc(func(event Event) {
+		if event.Type == FundingCharged {
+			funding[event.Position.Side] = funding[event.Position.Side].Add(event.Amount)
+		}
+	}))
+
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		if i == 0 {
+			_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(2)))
+			assert.NoError(t, err)
+			_, _, err = dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Sell, dec.New(1)))
+			assert.NoError(t, err)
+		}
+	}
+
+	// Both sides of the asset are charged each of the 3 hours they are open
+	assert.True(t, funding[broker.Buy].Equal(dec.New(0.6)), funding[broker.Buy].String())
+	assert.True(t, funding[broker.Sell].Equal(dec.New(0.3)), funding[broker.Sell].String())
+}
+
+func TestFundingFromPositionOpen(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		hours int
+		open  []int
+		close []int
+		want  []decimal.Decimal
+	}{
+		{
+			name:  "opens late",
+			hours: 7,
+			open:  []int{5},
+			want:  []decimal.Decimal{dec.New(0.1)},
+		},
+		{
+			name:  "reopens after flat period",
+			hours: 10,
+			open:  []int{0, 8},
+			close: []int{3},
+			want:  []decimal.Decimal{dec.New(0.3), dec.New(0.1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dealer := NewDealerWithCost(&PerpCoster{FundingHourPct: dec.New(0.01)})
+			dealer.SetInitialCapital(dec.New(1000))
+
+			funding := make(map[broker.DealID]decimal.Decimal)
+			dealer.Subscribe(SubscriberFunc(func(event Event) {
+				if event.Type == FundingCharged {
+					funding[event.Position.ID] = funding[event.Position.ID].Add(event.Amount)
+				}
+			}))
+
+			for i := 0; i < tt.hours; i++ {
+				price := market.Kline{Start: start.Add(time.Duration(i) * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)}
+				assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+				if slices.Contains(tt.open, i) {
+					_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(1)))
+					assert.NoError(t, err)
+				}
+				if slices.Contains(tt.close, i) {
+					_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Sell, dec.New(1)))
+					assert.NoError(t, err)
+				}
+			}
+
+			// Each position is charged only for the hours from its own open
+			positions, _, err := dealer.ListPositions(context.Background(), nil, nil)
+			assert.NoError(t, err)
+			assert.Len(t, positions, len(tt.want))
+			for i, position := range positions {
+				assert.True(t, funding[position.ID].Equal(tt.want[i]), funding[position.ID].String())
+			}
+		})
+	}
+}
+
+func TestPositionReversal(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(12), H: dec.New(12), L: dec.New(12), C: dec.New(12)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(11), H: dec.New(11), L: dec.New(11), C: dec.New(11)},
+	}
+
+	dealer := NewDealerWithCost(&PerpCoster{TransactionPct: dec.New(0.01)})
+	dealer.SetInitialCapital(dec.New(1000))
+	dealer.SetReversal(true)
+
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		switch i {
+		case 0:
+			_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(2)))
+			assert.NoError(t, err)
+		case 1:
+			// Reduce-only orders cannot reverse
+			_, _, err := dealer.PlaceOrder(context.Background(), broker.Order{Side: broker.Sell, Type: broker.Market, Size: dec.New(5), ReduceOnly: true})
+			assert.ErrorIs(t, err, ErrRejectedOrder)
+			_, _, err = dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Sell, dec.New(5)))
+			assert.NoError(t, err)
+		}
+	}
+
+	// Fee of 0.6 on the reversal is split 0.24 to the close and 0.36 to the new short
+	roundturns := dealer.simulator.RoundTurns()
+	assert.Len(t, roundturns, 1)
+	assert.True(t, roundturns[0].Profit.Equal(dec.New(3.56)), roundturns[0].Profit.String())
+
+	positions := dealer.simulator.Positions()
+	assert.Len(t, positions, 2)
+	assert.True(t, positions[0].State() == broker.PositionClosed)
+	assert.Equal(t, broker.Sell, positions[1].Side)
+	assert.True(t, positions[1].State() == broker.PositionOpen)
+	assert.True(t, positions[1].Size.Equal(dec.New(3)))
+	assert.True(t, positions[1].PNL.Equal(dec.New(2.64)), positions[1].PNL.String())
+
+	// The reversal is recorded as a fill for each position
+	fills := dealer.simulator.Fills()
+	assert.Len(t, fills, 3)
+	assert.Equal(t, positions[0].ID, fills[1].PositionID)
+	assert.True(t, fills[1].Fee.Equal(dec.New(0.24)), fills[1].Fee.String())
+	assert.Equal(t, positions[1].ID, fills[2].PositionID)
+	assert.True(t, fills[2].Fee.Equal(dec.New(0.36)), fills[2].Fee.String())
+}
+
+func TestScaleOutRealizesPNL(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(12), H: dec.New(12), L: dec.New(12), C: dec.New(12)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(14), H: dec.New(14), L: dec.New(14), C: dec.New(14)},
+	}
+
+	dealer := NewDealerWithCost(&PerpCoster{TransactionPct: dec.New(0.01)})
+	dealer.SetInitialCapital(dec.New(1000))
+
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		switch i {
+		case 0:
+			_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(4)))
+			assert.NoError(t, err)
+		case 1:
+			_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Sell, dec.New(2)))
+			assert.NoError(t, err)
+		case 2:
+			// Half the position is closed: realized profit net of the exit fee, and the remainder marked from the entry price
+			positions, _, err := dealer.ListPositions(context.Background(), nil, nil)
+			assert.NoError(t, err)
+			assert.True(t, positions[0].EntryPrice.Equal(dec.New(10.1)), positions[0].EntryPrice.String())
+			assert.True(t, positions[0].RealizedPNL.Equal(dec.New(3.56)), positions[0].RealizedPNL.String())
+			assert.True(t, positions[0].UnrealizedPNL.Equal(dec.New(7.8)), positions[0].UnrealizedPNL.String())
+			_, _, err = dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Sell, dec.New(2)))
+			assert.NoError(t, err)
+		}
+	}
+
+	positions, _, err := dealer.ListPositions(context.Background(), nil, nil)
+	assert.NoError(t, err)
+	assert.True(t, positions[0].State() == broker.PositionClosed)
+	assert.True(t, positions[0].RealizedPNL.Equal(dec.New(11.08)), positions[0].RealizedPNL.String())
+	assert.True(t, positions[0].UnrealizedPNL.IsZero(), positions[0].UnrealizedPNL.String())
+
+	roundturns, _, err := dealer.ListRoundTurns(context.Background(), nil, nil)
+	assert.NoError(t, err)
+	assert.True(t, roundturns[0].Profit.Equal(positions[0].RealizedPNL))
+
+	fills, _, err := dealer.ListFills(context.Background(), nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, fills, 3)
+	wantRealized := []decimal.Decimal{decimal.Zero, dec.New(3.56), dec.New(7.52)}
+	for i, fill := range fills {
+		assert.Equal(t, positions[0].ID, fill.PositionID)
+		assert.Equal(t, broker.Taker, fill.Liquidity)
+		assert.True(t, fill.RealizedPNL.Equal(wantRealized[i]), fill.RealizedPNL.String())
+	}
+	assert.True(t, fills[0].Fee.Equal(dec.New(0.4)), fills[0].Fee.String())
+}
+
+func TestListFilterAndPaginate(t *testing.T) {
+	start := time.Now()
+	btc := market.NewAsset("BTCUSD")
+	eth := market.NewAsset("ETHUSD")
+	ctx := context.Background()
+
+	dealer := NewDealer()
+	for i := 0; i < 3; i++ {
+		price := market.Kline{Start: start.Add(time.Duration(i) * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)}
+		assert.NoError(t, dealer.ReceiveAssetPrice(ctx, btc, price))
+		assert.NoError(t, dealer.ReceiveAssetPrice(ctx, eth, price))
+		switch i {
+		case 0:
+			_, _, err := dealer.PlaceOrder(ctx, broker.NewOrder(btc, broker.Buy, dec.New(1)))
+			assert.NoError(t, err)
+			_, _, err = dealer.PlaceOrder(ctx, broker.NewOrder(eth, broker.Sell, dec.New(2)))
+			assert.NoError(t, err)
+		case 1:
+			_, _, err := dealer.PlaceOrder(ctx, broker.NewOrder(eth, broker.Buy, dec.New(2)))
+			assert.NoError(t, err)
+			_, _, err = dealer.PlaceOrder(ctx, broker.NewOrder(eth, broker.Buy, dec.New(1)))
+			assert.NoError(t, err)
+		}
+	}
+
+	positions, res, err := dealer.ListPositions(ctx, &broker.PositionFilter{States: []broker.PositionState{broker.PositionOpen}}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, positions, 2)
+	assert.Equal(t, btc, positions[0].Asset)
+	assert.Equal(t, broker.Buy, positions[1].Side)
+	assert.Equal(t, web.Page{PageSize: 2, PageNum: 1, PagesTotal: 1}, res.Meta.Page)
+
+	positions, _, err = dealer.ListPositions(ctx, &broker.PositionFilter{Asset: eth, States: []broker.PositionState{broker.PositionClosed}}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, positions, 1)
+	assert.Equal(t, broker.Sell, positions[0].Side)
+
+	positions, res, err = dealer.ListPositions(ctx, nil, &web.ListOpts{PageSize: 2, PageNum: 2})
+	assert.NoError(t, err)
+	assert.Len(t, positions, 1)
+	assert.Equal(t, eth, positions[0].Asset)
+	assert.Equal(t, web.Page{PageSize: 2, PageNum: 2, PagesTotal: 2}, res.Meta.Page)
+
+	fills, _, err := dealer.ListFills(ctx, &broker.FillFilter{Asset: eth, Side: broker.Buy}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, fills, 2)
+
+	roundturns, _, err := dealer.ListRoundTurns(ctx, &broker.RoundTurnFilter{Asset: btc}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, roundturns)
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+)
+
+var _ Coster = (*BorrowCoster)(nil)
+var _ ScheduledFunder = (*BorrowCoster)(nil)
+
+// _year is the duration of a year used to accrue annualised rates.
+const _year = 365 * 24 * time.Hour
+
+// BorrowCoster implements the Coster interface with an annualised borrow fee charged on the notional of short positions,
+// accrued continuously from the time the position opened. Long positions are not charged.
+// The fee is accrued by FundingAt at the simulation time of each mark, in place of Funding.
+type BorrowCoster struct {
+	zeroCoster
+
+	// AnnualPct is the annualised borrow rate, e.g. 0.03 for 3% a year.
+	AnnualPct decimal.Decimal
+
+	lastCharged map[broker.DealID]time.Time
+}
+
+// NewBorrowCoster creates a new BorrowCoster.
+func NewBorrowCoster(annualPct decimal.Decimal) *BorrowCoster {
+	return &BorrowCoster{
+		AnnualPct: annualPct,
+	}
+}
+
+// FundingAt returns the borrow fee accrued on a short position since the last call up to the given time.
+// The returned amount is added to the position cost, so is negative to reduce the PNL of the short.
+func (c *BorrowCoster) FundingAt(position broker.Position, price decimal.Decimal, at time.Time) decimal.Decimal {
+	if position.State() != broker.PositionOpen || position.Side != broker.Sell {
+		return decimal.Zero
+	}
+	if c.lastCharged == nil {
+		c.lastCharged = make(map[broker.DealID]time.Time)
+	}
+
+	from, ok := c.lastCharged[position.ID]
+	if !ok {
+		from = position.OpenedAt
+	}
+	if !at.After(from) {
+		return decimal.Zero
+	}
+	c.lastCharged[position.ID] = at
+
+	years := decimal.NewFromInt(int64(at.Sub(from))).Div(decimal.NewFromInt(int64(_year)))
+	return position.Size.Mul(price).Mul(c.AnnualPct).Mul(years).Neg()
+}
+
+// borrowCosterState is the serializable state of a BorrowCoster.
+type borrowCosterState struct {
+	AnnualPct   decimal.Decimal
+	LastCharged map[broker.DealID]time.Time
+}
+
+// MarshalJSON includes the accrual state so that a Simulator snapshot can be restored.
+func (c *BorrowCoster) MarshalJSON() ([]byte, error) {
+	return json.Marshal(borrowCosterState{AnnualPct: c.AnnualPct, LastCharged: c.lastCharged})
+}
+
+// UnmarshalJSON restores the state output by MarshalJSON.
+func (c *BorrowCoster) UnmarshalJSON(data []byte) error {
+	var state borrowCosterState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	c.AnnualPct, c.lastCharged = state.AnnualPct, state.LastCharged
+	return nil
+}
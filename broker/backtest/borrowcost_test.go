@@ -0,0 +1,58 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestBorrowCosterFundingAt(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	cost := NewBorrowCoster(dec.New(0.0365))
+	short := broker.Position{ID: "short", OpenedAt: start, Side: broker.Sell, Size: dec.New(10)}
+	long := broker.Position{ID: "long", OpenedAt: start, Side: broker.Buy, Size: dec.New(10)}
+
+	act := cost.FundingAt(short, dec.New(100), start.Add(24*time.Hour))
+	assert.True(t, act.Round(8).Equal(dec.New(-0.1)), act.String())
+
+	act = cost.FundingAt(short, dec.New(100), start.Add(36*time.Hour))
+	assert.True(t, act.Round(8).Equal(dec.New(-0.05)), act.String())
+
+	act = cost.FundingAt(long, dec.New(100), start.Add(24*time.Hour))
+	assert.True(t, act.IsZero())
+}
+
+func TestEquityCostsOnShortTrade(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	cost := NewCompositeCoster(
+		NewCommissionCoster(dec.New(0.01), dec.New(1), decimal.Zero),
+		NewBorrowCoster(dec.New(0.365)),
+	)
+	dealer := NewDealerWithCost(cost)
+
+	for i := 0; i < 3; i++ {
+		price := market.Kline{Start: start.Add(time.Duration(i) * 24 * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)}
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		switch i {
+		case 0:
+			_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Sell, dec.New(100)))
+			assert.NoError(t, err)
+		case 2:
+			_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(100)))
+			assert.NoError(t, err)
+		}
+	}
+
+	// Commission of 1 on each fill and borrow of 1 a day for 2 days reduce the PNL of the short
+	act := dealer.simulator.Balance().Trade
+	assert.True(t, act.Round(4).Equal(dec.New(-4)), act.String())
+}
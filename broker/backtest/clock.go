@@ -4,6 +4,7 @@
 package backtest
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -14,6 +15,7 @@ import (
 // Clock helps ensure orders are processed in the sequence they are submitted.
 type Clock struct {
 	now      time.Time
+	epoch    time.Time
 	interval time.Duration
 	elapsed  time.Duration
 }
@@ -28,6 +30,7 @@ func NewClock() *Clock {
 // Start initializes the clock and resets all state.
 func (c *Clock) Start(start time.Time, tock time.Duration) {
 	c.now = start
+	c.epoch = start
 	c.interval = tock
 	c.elapsed = 0
 }
@@ -36,8 +39,9 @@ func (c *Clock) Start(start time.Time, tock time.Duration) {
 // When Now is next called it will be epoch + 1 tock interval.
 // Undefined behaviour if the given epoch is earlier than the current.
 func (c *Clock) Advance(epoch time.Time) {
-	c.elapsed += epoch.Sub(c.now)
+	c.elapsed += epoch.Sub(c.epoch)
 	c.now = epoch
+	c.epoch = epoch
 }
 
 // Now returns the time incremented by a tock,
@@ -53,8 +57,31 @@ func (c *Clock) Peek() time.Time {
 }
 
 // Elapsed returns the total elapsed duration since the start.
-// Elapsed time is calculated on each call to Advance.
+// Elapsed time is calculated on each call to Advance from the previous epoch, excluding the tocks in between.
 // Primarily used for calculating funding charges.
 func (c *Clock) Elapsed() time.Duration {
 	return c.elapsed
 }
+
+// clockState is the serializable state of a Clock.
+type clockState struct {
+	Now      time.Time
+	Epoch    time.Time
+	Interval time.Duration
+	Elapsed  time.Duration
+}
+
+// MarshalJSON is used to snapshot the clock state.
+func (c *Clock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(clockState{Now: c.now, Epoch: c.epoch, Interval: c.interval, Elapsed: c.elapsed})
+}
+
+// UnmarshalJSON restores the clock state output by MarshalJSON.
+func (c *Clock) UnmarshalJSON(data []byte) error {
+	var state clockState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	c.now, c.epoch, c.interval, c.elapsed = state.Now, state.Epoch, state.Interval, state.Elapsed
+	return nil
+}
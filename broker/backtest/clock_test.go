@@ -16,6 +16,7 @@ func TestClockStart(t *testing.T) {
 
 	want := &Clock{
 		now:      giveStart,
+		epoch:    giveStart,
 		interval: giveInterval,
 		elapsed:  0,
 	}
@@ -31,6 +32,7 @@ func TestClockAdvance(t *testing.T) {
 
 	want := Clock{
 		now:     giveEpoch,
+		epoch:   giveEpoch,
 		elapsed: time.Hour * 2,
 	}
 
@@ -50,3 +52,17 @@ func TestClockNow(t *testing.T) {
 	act := clock.Now()
 	assert.Equal(t, want, act)
 }
+
+func TestClockElapsedExcludesTocks(t *testing.T) {
+	start := time.Now()
+
+	clock := NewClock()
+	clock.Start(start, time.Millisecond)
+	clock.Now()
+	clock.Now()
+	clock.Advance(start.Add(time.Hour))
+	clock.Now()
+	clock.Advance(start.Add(time.Hour * 2))
+
+	assert.Equal(t, time.Hour*2, clock.Elapsed())
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+)
+
+var _ Coster = (*CommissionCoster)(nil)
+
+// CommissionCoster implements the Coster interface with a per-share (or per-contract) commission,
+// subject to a minimum and a cap per fill, as charged by equity and futures brokers.
+type CommissionCoster struct {
+	zeroCoster
+
+	// PerUnit is the commission per share or contract filled.
+	PerUnit decimal.Decimal
+
+	// Min is the minimum commission per fill.
+	Min decimal.Decimal
+
+	// Max caps the commission per fill, zero is uncapped.
+	Max decimal.Decimal
+
+	// MaxPct caps the commission per fill as a fraction of the fill notional, zero is uncapped.
+	MaxPct decimal.Decimal
+}
+
+// NewCommissionCoster creates a new CommissionCoster.
+func NewCommissionCoster(perUnit, min, max decimal.Decimal) *CommissionCoster {
+	return &CommissionCoster{
+		PerUnit: perUnit,
+		Min:     min,
+		Max:     max,
+	}
+}
+
+// Transaction returns the commission for the fill size, raised to the minimum and limited by the caps.
+func (c *CommissionCoster) Transaction(order broker.Order) decimal.Decimal {
+	fee := decimal.Max(order.FilledSize.Mul(c.PerUnit), c.Min)
+	if c.Max.IsPositive() {
+		fee = decimal.Min(fee, c.Max)
+	}
+	if c.MaxPct.IsPositive() {
+		fee = decimal.Min(fee, order.FilledSize.Mul(order.FilledPrice).Mul(c.MaxPct))
+	}
+	return fee
+}
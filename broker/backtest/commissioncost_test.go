@@ -0,0 +1,51 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestCommissionCosterTransaction(t *testing.T) {
+	cost := NewCommissionCoster(dec.New(0.005), dec.New(1), dec.New(10))
+	cost.MaxPct = dec.New(0.01)
+
+	tests := []struct {
+		name string
+		give broker.Order
+		want decimal.Decimal
+	}{
+		{
+			name: "per share",
+			give: broker.Order{FilledPrice: dec.New(50), FilledSize: dec.New(400)},
+			want: dec.New(2),
+		},
+		{
+			name: "minimum",
+			give: broker.Order{FilledPrice: dec.New(50), FilledSize: dec.New(100)},
+			want: dec.New(1),
+		},
+		{
+			name: "capped",
+			give: broker.Order{FilledPrice: dec.New(50), FilledSize: dec.New(4000)},
+			want: dec.New(10),
+		},
+		{
+			name: "capped by notional",
+			give: broker.Order{FilledPrice: dec.New(0.1), FilledSize: dec.New(1000)},
+			want: dec.New(1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := cost.Transaction(tt.give)
+			assert.True(t, act.Equal(tt.want), act.String())
+		})
+	}
+}
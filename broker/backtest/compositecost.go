@@ -0,0 +1,89 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+var _ Coster = (*CompositeCoster)(nil)
+
+// CompositeCoster implements the Coster interface as the sum of the costs of several models,
+// e.g. a commission, regulatory fees and a short borrow fee for an equity backtest.
+type CompositeCoster struct {
+	Costers []Coster
+}
+
+// NewCompositeCoster creates a new CompositeCoster.
+func NewCompositeCoster(costers ...Coster) *CompositeCoster {
+	return &CompositeCoster{
+		Costers: costers,
+	}
+}
+
+// Slippage returns the sum of the slippage of each model.
+func (c *CompositeCoster) Slippage(order broker.Order, price decimal.Decimal, kline market.Kline) decimal.Decimal {
+	var total decimal.Decimal
+	for _, cost := range c.Costers {
+		total = total.Add(cost.Slippage(order, price, kline))
+	}
+	return total
+}
+
+// Spread returns the sum of the spread of each model.
+func (c *CompositeCoster) Spread(price decimal.Decimal) decimal.Decimal {
+	var total decimal.Decimal
+	for _, cost := range c.Costers {
+		total = total.Add(cost.Spread(price))
+	}
+	return total
+}
+
+// Transaction returns the sum of the transaction cost of each model.
+func (c *CompositeCoster) Transaction(order broker.Order) decimal.Decimal {
+	var total decimal.Decimal
+	for _, cost := range c.Costers {
+		total = total.Add(cost.Transaction(order))
+	}
+	return total
+}
+
+// Funding returns the sum of the funding of each model.
+// Simulator calls FundingAt on each model that implements ScheduledFunder in place of this method.
+func (c *CompositeCoster) Funding(position broker.Position, price decimal.Decimal, elapsed time.Duration) decimal.Decimal {
+	var total decimal.Decimal
+	for _, cost := range c.Costers {
+		total = total.Add(cost.Funding(position, price, elapsed))
+	}
+	return total
+}
+
+// MarshalJSON outputs the state of each model in sequence.
+func (c *CompositeCoster) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Costers)
+}
+
+// UnmarshalJSON restores the state of each model output by MarshalJSON,
+// the composite must be created with the same models in the same sequence.
+func (c *CompositeCoster) UnmarshalJSON(data []byte) error {
+	var state []json.RawMessage
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if len(state) != len(c.Costers) {
+		return errors.New("composite coster models do not match snapshot")
+	}
+	for i := range c.Costers {
+		if err := json.Unmarshal(state[i], c.Costers[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -8,11 +8,14 @@ import (
 
 	"github.com/shopspring/decimal"
 	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
 )
 
 // Coster is a cost model used by a dealer to apply trading charges and fees.
 type Coster interface {
-	Slippage(price decimal.Decimal) decimal.Decimal
+	// Slippage returns the price slippage of filling the remaining size of the order at the price,
+	// given the kline of the order asset current at the time of the fill.
+	Slippage(order broker.Order, price decimal.Decimal, kline market.Kline) decimal.Decimal
 	Spread(price decimal.Decimal) decimal.Decimal
 	Transaction(broker.Order) decimal.Decimal
 	Funding(position broker.Position, price decimal.Decimal, elapsed time.Duration) decimal.Decimal
@@ -0,0 +1,217 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/conv"
+	"github.com/thecolngroup/gou/dec"
+	"golang.org/x/exp/slices"
+)
+
+// MakeCoster mints a new cost model from a config source.
+type MakeCoster func(config map[string]any) (Coster, error)
+
+// Costers maps a config name to a cost model maker, used by MakeCosterFromConfig.
+// Add to the map to make a custom cost model selectable by name.
+// The perp, tieredfee and fundingrate models each cost spread, slippage and funding using the perp keys,
+// so only one of them can be selected, combine it with the other models as needed.
+var Costers = map[string]MakeCoster{
+	"perp":          makePerpCosterFromConfig,
+	"tieredfee":     makeTieredFeeCosterFromConfig,
+	"fundingrate":   makeFundingRateCosterFromConfig,
+	"commission":    makeCommissionCosterFromConfig,
+	"regulatoryfee": makeRegulatoryFeeCosterFromConfig,
+	"borrow":        makeBorrowCosterFromConfig,
+	"impact":        makeImpactCosterFromConfig,
+}
+
+// _perpCosters are the cost models that embed a PerpCoster.
+var _perpCosters = []string{"perp", "tieredfee", "fundingrate"}
+
+// MakeCosterFromConfig mints the cost models named by the 'coster' key of a config source,
+// which is a single name or a list of names combined by a CompositeCoster.
+// Without a 'coster' key the model is selected by the keys present: tieredfee if any of makerpct, takerpct
+// or feetiers is set, fundingrate if fundingratepath is set, otherwise perp.
+// Returns an error if the tiered fee or funding rate keys are set without selecting their model.
+func MakeCosterFromConfig(config map[string]any) (Coster, error) {
+	hasTierKeys := config["makerpct"] != nil || config["takerpct"] != nil || config["feetiers"] != nil
+	hasFundingKeys := config["fundingratepath"] != nil
+
+	var names []string
+	switch v := config["coster"].(type) {
+	case nil:
+		switch {
+		case hasTierKeys:
+			names = []string{"tieredfee"}
+		case hasFundingKeys:
+			names = []string{"fundingrate"}
+		default:
+			names = []string{"perp"}
+		}
+	case string:
+		names = []string{v}
+	case []any:
+		for i := range v {
+			names = append(names, conv.ToString(v[i]))
+		}
+	default:
+		return nil, errors.New("'coster' must be a name or list of names")
+	}
+	for i := range names {
+		names[i] = strings.ToLower(names[i])
+	}
+
+	var perps []string
+	for _, name := range names {
+		if slices.Contains(_perpCosters, name) {
+			perps = append(perps, name)
+		}
+	}
+	if len(perps) > 1 {
+		return nil, fmt.Errorf("only one of %s can be selected, they each cost spread, slippage and funding", strings.Join(perps, ", "))
+	}
+	if hasTierKeys && !slices.Contains(names, "tieredfee") {
+		return nil, errors.New("'makerpct', 'takerpct' and 'feetiers' require the tieredfee coster")
+	}
+	if hasFundingKeys && !slices.Contains(names, "fundingrate") {
+		return nil, errors.New("'fundingratepath' requires the fundingrate coster")
+	}
+
+	costers := make([]Coster, 0, len(names))
+	for _, name := range names {
+		maker, ok := Costers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown coster: %s", name)
+		}
+		cost, err := maker(config)
+		if err != nil {
+			return nil, err
+		}
+		costers = append(costers, cost)
+	}
+
+	if len(costers) == 1 {
+		return costers[0], nil
+	}
+	return NewCompositeCoster(costers...), nil
+}
+
+// makePerpCosterFromConfig creates a PerpCoster from keys: spreadpct, slippagepct, transactionpct, fundinghourpct.
+func makePerpCosterFromConfig(config map[string]any) (Coster, error) {
+	perp := perpCosterFromConfig(config)
+	return &perp, nil
+}
+
+// perpCosterFromConfig returns a PerpCoster from keys: spreadpct, slippagepct, transactionpct, fundinghourpct.
+func perpCosterFromConfig(config map[string]any) PerpCoster {
+	return PerpCoster{
+		SpreadPct:      dec.New(conv.ToFloat(config["spreadpct"])),
+		SlippagePct:    dec.New(conv.ToFloat(config["slippagepct"])),
+		TransactionPct: dec.New(conv.ToFloat(config["transactionpct"])),
+		FundingHourPct: dec.New(conv.ToFloat(config["fundinghourpct"])),
+	}
+}
+
+// makeTieredFeeCosterFromConfig creates a TieredFeeCoster from keys: makerpct, takerpct, feewindowdays,
+// and feetiers, an array of tables with keys: minnotional, makerpct, takerpct.
+// The embedded PerpCoster is created from the perp keys.
+func makeTieredFeeCosterFromConfig(config map[string]any) (Coster, error) {
+	tiers := []FeeTier{{
+		MakerPct: dec.New(conv.ToFloat(config["makerpct"])),
+		TakerPct: dec.New(conv.ToFloat(config["takerpct"])),
+	}}
+
+	if config["feetiers"] != nil {
+		items, ok := config["feetiers"].([]any)
+		if !ok {
+			return nil, errors.New("'feetiers' must be an array of tables")
+		}
+		for _, item := range items {
+			tier, ok := item.(map[string]any)
+			if !ok {
+				return nil, errors.New("'feetiers' must be an array of tables")
+			}
+			tiers = append(tiers, FeeTier{
+				MinNotional: dec.New(conv.ToFloat(configValue(tier, "minnotional"))),
+				MakerPct:    dec.New(conv.ToFloat(configValue(tier, "makerpct"))),
+				TakerPct:    dec.New(conv.ToFloat(configValue(tier, "takerpct"))),
+			})
+		}
+	}
+
+	cost := NewTieredFeeCoster(tiers...)
+	cost.PerpCoster = perpCosterFromConfig(config)
+	if days := conv.ToFloat(config["feewindowdays"]); days > 0 {
+		cost.Window = time.Duration(days * float64(24*time.Hour))
+	}
+	return cost, nil
+}
+
+// makeFundingRateCosterFromConfig creates a FundingRateCoster from keys: fundingratepath.
+// The embedded PerpCoster is created from the perp keys.
+func makeFundingRateCosterFromConfig(config map[string]any) (Coster, error) {
+	path, ok := config["fundingratepath"]
+	if !ok {
+		return nil, errors.New("'fundingratepath' key not found")
+	}
+	rates, err := market.ReadFundingRatesFromCSV(conv.ToString(path))
+	if err != nil {
+		return nil, err
+	}
+	cost := NewFundingRateCoster(rates)
+	cost.PerpCoster = perpCosterFromConfig(config)
+	return cost, nil
+}
+
+// makeCommissionCosterFromConfig creates a CommissionCoster from keys:
+// commissionperunit, commissionmin, commissionmax, commissionmaxpct.
+func makeCommissionCosterFromConfig(config map[string]any) (Coster, error) {
+	cost := NewCommissionCoster(
+		dec.New(conv.ToFloat(config["commissionperunit"])),
+		dec.New(conv.ToFloat(config["commissionmin"])),
+		dec.New(conv.ToFloat(config["commissionmax"])),
+	)
+	cost.MaxPct = dec.New(conv.ToFloat(config["commissionmaxpct"]))
+	return cost, nil
+}
+
+// makeRegulatoryFeeCosterFromConfig creates a RegulatoryFeeCoster from keys:
+// exchangefeeperunit, sellfeepct, sellfeeperunit, sellfeeperunitmax.
+func makeRegulatoryFeeCosterFromConfig(config map[string]any) (Coster, error) {
+	return &RegulatoryFeeCoster{
+		ExchangePerUnit: dec.New(conv.ToFloat(config["exchangefeeperunit"])),
+		SellNotionalPct: dec.New(conv.ToFloat(config["sellfeepct"])),
+		SellPerUnit:     dec.New(conv.ToFloat(config["sellfeeperunit"])),
+		SellPerUnitMax:  dec.New(conv.ToFloat(config["sellfeeperunitmax"])),
+	}, nil
+}
+
+// makeBorrowCosterFromConfig creates a BorrowCoster from keys: borrowannualpct.
+func makeBorrowCosterFromConfig(config map[string]any) (Coster, error) {
+	return NewBorrowCoster(dec.New(conv.ToFloat(config["borrowannualpct"]))), nil
+}
+
+// makeImpactCosterFromConfig creates an ImpactCoster from keys: impactcoef, impactmaxpct.
+func makeImpactCosterFromConfig(config map[string]any) (Coster, error) {
+	cost := NewImpactCoster(dec.New(conv.ToFloat(config["impactcoef"])))
+	cost.MaxPct = dec.New(conv.ToFloat(config["impactmaxpct"]))
+	return cost, nil
+}
+
+// configValue returns the value for the key ignoring case,
+// as keys of tables nested in arrays are not lowercased by the config reader.
+func configValue(config map[string]any, key string) any {
+	for k, v := range config {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
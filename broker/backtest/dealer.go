@@ -40,6 +40,66 @@ func (d *Dealer) SetInitialCapital(amount decimal.Decimal) {
 	d.simulator.SetInitialCapital(amount)
 }
 
+// SetCurrency sets the base currency of the account and the converter used to settle assets quoted in other currencies.
+func (d *Dealer) SetCurrency(base string, converter *market.Converter) {
+	d.simulator.SetCurrency(base, converter)
+}
+
+// SetPositionMode sets how the positions of an asset are tracked, defaults to OneWay.
+func (d *Dealer) SetPositionMode(mode PositionMode) {
+	d.simulator.SetPositionMode(mode)
+}
+
+// SetReversal enables an order larger than an open position on the opposite side to reverse the position.
+func (d *Dealer) SetReversal(enabled bool) {
+	d.simulator.SetReversal(enabled)
+}
+
+// SetLiquidity sets the liquidity model used to limit the size of each fill.
+// A nil model fills orders in full.
+func (d *Dealer) SetLiquidity(liquidity Liquidity) {
+	d.simulator.SetLiquidity(liquidity)
+}
+
+// SetMargin sets the margin model used to limit leverage and liquidate positions.
+// A nil model permits unlimited leverage.
+func (d *Dealer) SetMargin(margin *Margin) {
+	d.simulator.SetMargin(margin)
+}
+
+// SetIntrabarPath sets the model used to sequence the processing of open orders within a kline.
+// A nil model processes orders in the sequence they were placed.
+func (d *Dealer) SetIntrabarPath(path IntrabarPath) {
+	d.simulator.SetIntrabarPath(path)
+}
+
+// SetLatency sets the model of the delay between an order being placed and becoming live.
+// A nil model opens orders immediately.
+func (d *Dealer) SetLatency(latency Latency) {
+	d.simulator.SetLatency(latency)
+}
+
+// SetLowerTimeframe sets a finer grained price feed used to match orders for the given asset.
+// Use an empty asset for the price feed given to ReceivePrice.
+func (d *Dealer) SetLowerTimeframe(asset market.Asset, feed *LowerTimeframe) {
+	d.simulator.SetLowerTimeframe(asset, feed)
+}
+
+// Subscribe registers a subscriber to receive the events emitted by the simulator.
+func (d *Dealer) Subscribe(sub Subscriber) {
+	d.simulator.Subscribe(sub)
+}
+
+// Snapshot serializes the simulator state to JSON.
+func (d *Dealer) Snapshot() ([]byte, error) {
+	return d.simulator.Snapshot()
+}
+
+// Restore replaces the simulator state with a snapshot output by Snapshot.
+func (d *Dealer) Restore(data []byte) error {
+	return d.simulator.Restore(data)
+}
+
 // GetBalance returns the current balance of the dealer.
 func (d *Dealer) GetBalance(ctx context.Context) (*broker.AccountBalance, *web.Response, error) {
 	acc := d.simulator.Balance()
@@ -52,20 +112,72 @@ func (d *Dealer) PlaceOrder(ctx context.Context, order broker.Order) (*broker.Or
 	return &order, nil, err
 }
 
+// PlaceBracketOrder places an entry order with optional stop and take profit legs managed as one-cancels-other.
+func (d *Dealer) PlaceBracketOrder(ctx context.Context, bracket broker.BracketOrder) (*broker.BracketOrder, *web.Response, error) {
+	bracket, err := d.simulator.AddBracketOrder(bracket)
+	return &bracket, nil, err
+}
+
 // CancelOrders cancels all open (resting) orders on the dealer.
 func (d *Dealer) CancelOrders(ctx context.Context) (*web.Response, error) {
 	d.simulator.CancelOrders()
 	return nil, nil
 }
 
-// ListPositions returns all historical (closed) and open positions.
-func (d *Dealer) ListPositions(ctx context.Context, opts *web.ListOpts) ([]broker.Position, *web.Response, error) {
-	return d.simulator.Positions(), nil, nil
+// CancelOrder cancels an open (resting) order by ID.
+func (d *Dealer) CancelOrder(ctx context.Context, id broker.DealID) (*broker.Order, *web.Response, error) {
+	order, err := d.simulator.CancelOrder(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &order, nil, nil
+}
+
+// AmendOrder amends the price and/or size of an open (resting) order by ID.
+func (d *Dealer) AmendOrder(ctx context.Context, id broker.DealID, opts broker.AmendOpts) (*broker.Order, *web.Response, error) {
+	order, err := d.simulator.AmendOrder(id, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &order, nil, nil
+}
+
+// GetOrder returns an order by ID.
+func (d *Dealer) GetOrder(ctx context.Context, id broker.DealID) (*broker.Order, *web.Response, error) {
+	order, err := d.simulator.Order(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &order, nil, nil
+}
+
+// ListOrders returns the page of historical and open orders that match the filter.
+func (d *Dealer) ListOrders(ctx context.Context, filter *broker.OrderFilter, opts *web.ListOpts) ([]broker.Order, *web.Response, error) {
+	orders, page := web.Paginate(d.simulator.ListOrders(filter), opts)
+	return orders, newPageResponse(page), nil
+}
+
+// ListPositions returns the page of historical (closed) and open positions that match the filter.
+func (d *Dealer) ListPositions(ctx context.Context, filter *broker.PositionFilter, opts *web.ListOpts) ([]broker.Position, *web.Response, error) {
+	positions, page := web.Paginate(d.simulator.ListPositions(filter), opts)
+	return positions, newPageResponse(page), nil
+}
+
+// ListRoundTurns returns the page of historical round-turns that match the filter.
+func (d *Dealer) ListRoundTurns(ctx context.Context, filter *broker.RoundTurnFilter, opts *web.ListOpts) ([]broker.RoundTurn, *web.Response, error) {
+	roundturns, page := web.Paginate(d.simulator.ListRoundTurns(filter), opts)
+	return roundturns, newPageResponse(page), nil
 }
 
-// ListRoundTurns returns all historical round-turns.
-func (d *Dealer) ListRoundTurns(ctx context.Context, opts *web.ListOpts) ([]broker.RoundTurn, *web.Response, error) {
-	return d.simulator.RoundTurns(), nil, nil
+// ListFills returns the page of historical fills that match the filter.
+func (d *Dealer) ListFills(ctx context.Context, filter *broker.FillFilter, opts *web.ListOpts) ([]broker.Fill, *web.Response, error) {
+	fills, page := web.Paginate(d.simulator.ListFills(filter), opts)
+	return fills, newPageResponse(page), nil
+}
+
+// newPageResponse returns a response with the pagination information of a list call.
+func newPageResponse(page web.Page) *web.Response {
+	return &web.Response{Meta: web.ResponseMetadata{Page: page}}
 }
 
 // EquityHistory returns the equity history (equity curve) of the dealer.
@@ -77,3 +189,23 @@ func (d *Dealer) EquityHistory() broker.EquitySeries {
 func (d *Dealer) ReceivePrice(ctx context.Context, price market.Kline) error {
 	return d.simulator.Next(price)
 }
+
+// ReceiveOrderBook supplies the latest order book for the given asset to the simulator,
+// an empty asset is the default price feed. Market orders are filled by walking the book.
+func (d *Dealer) ReceiveOrderBook(ctx context.Context, asset market.Asset, book *market.OrderBook) error {
+	d.simulator.SetOrderBook(asset, book)
+	return nil
+}
+
+// ReceiveTrade supplies the next trade print for the given asset to the simulator,
+// an empty asset is the default price feed.
+// Use to backtest trade by trade in place of klines.
+func (d *Dealer) ReceiveTrade(ctx context.Context, asset market.Asset, trade market.Trade) error {
+	return d.simulator.NextTrade(asset, trade)
+}
+
+// ReceiveAssetPrice supplies the next market price for the given asset to the simulator.
+// Use to backtest a basket of assets with a single dealer.
+func (d *Dealer) ReceiveAssetPrice(ctx context.Context, asset market.Asset, price market.Kline) error {
+	return d.simulator.NextAsset(asset, price)
+}
@@ -0,0 +1,82 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+)
+
+// EventType is the type of change in Simulator state.
+type EventType int
+
+const (
+	// OrderOpened is emitted when an order is opened (accepted) by the simulator.
+	OrderOpened EventType = iota + 1
+
+	// OrderFilled is emitted for each fill of an order, the event order holds the size and price of the fill.
+	OrderFilled
+
+	// OrderCancelled is emitted when an order is closed without being filled in full.
+	OrderCancelled
+
+	// OrderRejected is emitted when an order is rejected, the event reason describes why.
+	OrderRejected
+
+	// PositionOpened is emitted when a fill opens a new position.
+	PositionOpened
+
+	// PositionAdjusted is emitted when a fill changes the size of an open position.
+	PositionAdjusted
+
+	// PositionClosed is emitted when a fill closes a position and its PNL is realized.
+	PositionClosed
+
+	// FundingCharged is emitted when funding is charged to an open position, the event amount is the charge.
+	FundingCharged
+
+	// EquityMarked is emitted at the end of each epoch, the event amount is the marked equity.
+	EquityMarked
+
+	// PositionLiquidated is emitted when a position is force closed by a liquidation order,
+	// the event amount is the liquidation fee. The liquidation order is filled and its events emitted beforehand.
+	PositionLiquidated
+)
+
+// String returns the string representation of the event type.
+func (t EventType) String() string {
+	return [...]string{"", "OrderOpened", "OrderFilled", "OrderCancelled", "OrderRejected",
+		"PositionOpened", "PositionAdjusted", "PositionClosed", "FundingCharged", "EquityMarked", "PositionLiquidated"}[t]
+}
+
+// MarshalText is used to output as a string for JSON rendering.
+func (t EventType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// Event is a change in Simulator state.
+// Order or Position is set depending on the event type.
+type Event struct {
+	Type     EventType        `json:"type"`
+	Time     time.Time        `json:"time"`
+	Order    *broker.Order    `json:"order,omitempty"`
+	Position *broker.Position `json:"position,omitempty"`
+	Amount   decimal.Decimal  `json:"amount"`
+	Reason   string           `json:"reason,omitempty"`
+}
+
+// Subscriber receives events emitted by a Simulator.
+type Subscriber interface {
+	ReceiveEvent(Event)
+}
+
+// SubscriberFunc is an adapter to use an ordinary function as a Subscriber.
+type SubscriberFunc func(Event)
+
+// ReceiveEvent calls f(event).
+func (f SubscriberFunc) ReceiveEvent(event Event) {
+	f(event)
+}
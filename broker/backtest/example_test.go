@@ -52,7 +52,7 @@ func Example() {
 	dealer.PlaceOrder(context.Background(), broker.NewOrder(asset, broker.Sell, dec.New(1)))
 
 	// Generate a performance report from the dealer execution history
-	roundturns, _, _ := dealer.ListRoundTurns(context.Background(), nil)
+	roundturns, _, _ := dealer.ListRoundTurns(context.Background(), nil, nil)
 	equity := dealer.EquityHistory()
 	report := perf.NewPerformanceReport(roundturns, equity)
 
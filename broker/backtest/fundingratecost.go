@@ -0,0 +1,152 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+var _ Coster = (*FundingRateCoster)(nil)
+var _ ScheduledFunder = (*FundingRateCoster)(nil)
+
+// ScheduledFunder is implemented by cost models that settle funding at scheduled times.
+// When implemented by the Simulator cost model, FundingAt is called in place of Funding with the simulation time.
+type ScheduledFunder interface {
+	FundingAt(position broker.Position, price decimal.Decimal, at time.Time) decimal.Decimal
+}
+
+// FundingFlow is a funding cash flow settled on a position.
+// A positive amount is paid by the position and a negative amount is received.
+type FundingFlow struct {
+	Time       time.Time
+	PositionID broker.DealID
+	Asset      market.Asset
+	Rate       decimal.Decimal
+	Amount     decimal.Decimal
+}
+
+// FundingRateCoster implements the Coster interface with funding settled from a historical funding rate series,
+// such as the Binance 8 hourly fundingRate dumps.
+// At each settlement time the rate is applied to the position notional, longs pay and shorts receive a positive rate.
+// Spread, slippage and transaction costs are costed by the embedded PerpCoster, its FundingHourPct is not used.
+type FundingRateCoster struct {
+	PerpCoster
+
+	rates       map[market.Asset][]market.FundingRate
+	lastSettled map[broker.DealID]time.Time
+	flows       []FundingFlow
+}
+
+// NewFundingRateCoster creates a new FundingRateCoster with the default funding rate series used for all assets.
+func NewFundingRateCoster(rates []market.FundingRate) *FundingRateCoster {
+	c := &FundingRateCoster{}
+	c.SetAssetRates(market.Asset{}, rates)
+	return c
+}
+
+// SetAssetRates sets the funding rate series for the given asset,
+// an empty asset sets the default series used for assets without a series of their own.
+func (c *FundingRateCoster) SetAssetRates(asset market.Asset, rates []market.FundingRate) {
+	if c.rates == nil {
+		c.rates = make(map[market.Asset][]market.FundingRate)
+	}
+	sorted := make([]market.FundingRate, len(rates))
+	copy(sorted, rates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+	c.rates[asset] = sorted
+}
+
+// Funding returns zero as funding is settled at scheduled times by FundingAt.
+func (c *FundingRateCoster) Funding(position broker.Position, price decimal.Decimal, elapsed time.Duration) decimal.Decimal {
+	return decimal.Zero
+}
+
+// FundingAt returns the funding settled on the position since the last call up to and including the given time,
+// and records a FundingFlow for each settlement.
+// The returned amount is added to the position cost, so a positive rate reduces the PNL of a long
+// and increases the PNL of a short, consistent with PerpCoster.
+func (c *FundingRateCoster) FundingAt(position broker.Position, price decimal.Decimal, at time.Time) decimal.Decimal {
+	if position.State() != broker.PositionOpen {
+		return decimal.Zero
+	}
+	if c.lastSettled == nil {
+		c.lastSettled = make(map[broker.DealID]time.Time)
+	}
+
+	from, ok := c.lastSettled[position.ID]
+	if !ok {
+		from = position.OpenedAt
+	}
+	c.lastSettled[position.ID] = at
+
+	rates, ok := c.rates[position.Asset]
+	if !ok {
+		rates = c.rates[market.Asset{}]
+	}
+
+	sign := decimal.NewFromInt(1)
+	if position.Side == broker.Sell {
+		sign = sign.Neg()
+	}
+	notional := position.Size.Mul(price)
+
+	var total decimal.Decimal
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].Time.After(from) })
+	for ; i < len(rates) && !rates[i].Time.After(at); i++ {
+		charge := notional.Mul(rates[i].Rate)
+		total = total.Add(charge)
+		c.flows = append(c.flows, FundingFlow{
+			Time:       rates[i].Time,
+			PositionID: position.ID,
+			Asset:      position.Asset,
+			Rate:       rates[i].Rate,
+			Amount:     charge.Mul(sign),
+		})
+	}
+
+	return total
+}
+
+// Flows returns the funding cash flows settled on all positions.
+func (c *FundingRateCoster) Flows() []FundingFlow {
+	return c.flows
+}
+
+// fundingRateCosterState is the serializable state of a FundingRateCoster, excluding the rate series.
+type fundingRateCosterState struct {
+	Perp        json.RawMessage
+	LastSettled map[broker.DealID]time.Time
+	Flows       []FundingFlow
+}
+
+// MarshalJSON includes the settlement state so that a Simulator snapshot can be restored.
+// The funding rate series is not included and must be set on the restored coster.
+func (c *FundingRateCoster) MarshalJSON() ([]byte, error) {
+	perp, err := c.PerpCoster.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fundingRateCosterState{Perp: perp, LastSettled: c.lastSettled, Flows: c.flows})
+}
+
+// UnmarshalJSON restores the state output by MarshalJSON.
+func (c *FundingRateCoster) UnmarshalJSON(data []byte) error {
+	var state fundingRateCosterState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if err := c.PerpCoster.UnmarshalJSON(state.Perp); err != nil {
+		return err
+	}
+	c.lastSettled, c.flows = state.LastSettled, state.Flows
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestFundingRateCosterFundingAt(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	rates := []market.FundingRate{
+		{Time: start.Add(8 * time.Hour), Rate: dec.New(0.001)},
+		{Time: start.Add(16 * time.Hour), Rate: dec.New(-0.002)},
+		{Time: start.Add(24 * time.Hour), Rate: dec.New(0.003)},
+	}
+	long := broker.Position{ID: "long", OpenedAt: start.Add(time.Hour), Side: broker.Buy, Size: dec.New(2)}
+	short := broker.Position{ID: "short", OpenedAt: start.Add(time.Hour), Side: broker.Sell, Size: dec.New(2)}
+
+	tests := []struct {
+		name         string
+		givePosition broker.Position
+		giveAt       []time.Time
+		want         []decimal.Decimal
+	}{
+		{
+			name:         "long pays positive rate and receives negative rate",
+			givePosition: long,
+			giveAt:       []time.Time{start.Add(7 * time.Hour), start.Add(8 * time.Hour), start.Add(20 * time.Hour)},
+			want:         []decimal.Decimal{dec.New(0), dec.New(0.2), dec.New(-0.4)},
+		},
+		{
+			name:         "short cost is the same sign as long and its flow is received",
+			givePosition: short,
+			giveAt:       []time.Time{start.Add(9 * time.Hour)},
+			want:         []decimal.Decimal{dec.New(0.2)},
+		},
+		{
+			name:         "settlements between calls are summed",
+			givePosition: long,
+			giveAt:       []time.Time{start.Add(25 * time.Hour)},
+			want:         []decimal.Decimal{dec.New(0.4)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost := NewFundingRateCoster(rates)
+			for i, at := range tt.giveAt {
+				act := cost.FundingAt(tt.givePosition, dec.New(100), at)
+				assert.True(t, act.Equal(tt.want[i]), act.String())
+			}
+			for _, flow := range cost.Flows() {
+				if tt.givePosition.Side == broker.Sell {
+					assert.True(t, flow.Amount.Equal(flow.Rate.Mul(dec.New(-200))))
+				} else {
+					assert.True(t, flow.Amount.Equal(flow.Rate.Mul(dec.New(200))))
+				}
+			}
+		})
+	}
+}
+
+func TestFundingRateCosterInSimulator(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	cost := NewFundingRateCoster([]market.FundingRate{
+		{Time: start.Add(8 * time.Hour), Rate: dec.New(0.001)},
+		{Time: start.Add(16 * time.Hour), Rate: dec.New(0.001)},
+	})
+	sim := NewSimulatorWithCost(cost)
+
+	for i := 0; i < 12; i++ {
+		price := market.Kline{Start: start.Add(time.Duration(i) * 2 * time.Hour), O: dec.New(100), H: dec.New(100), L: dec.New(100), C: dec.New(100)}
+		assert.NoError(t, sim.Next(price))
+		if i == 0 {
+			_, err := sim.AddOrder(broker.NewOrder(market.Asset{}, broker.Sell, dec.New(1)))
+			assert.NoError(t, err)
+		}
+	}
+
+	flows := cost.Flows()
+	assert.Len(t, flows, 2)
+	assert.True(t, flows[0].Amount.Equal(dec.New(-0.1)))
+	assert.True(t, sim.Balance().Equity.Equal(dec.New(0.2)), sim.Balance().Equity.String())
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"math"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+var _ Coster = (*ImpactCoster)(nil)
+
+// ImpactCoster implements the Coster interface with square-root market impact slippage.
+// Slippage is the range of the current kline scaled by the square root of the order's share of the kline volume:
+//
+//	slippage = Coefficient * (H - L) * sqrt(size / volume)
+//
+// Wide, volatile bars and large orders relative to the traded volume are filled at worse prices.
+// A kline without volume is treated as if the order were the entire volume.
+type ImpactCoster struct {
+	zeroCoster
+
+	// Coefficient scales the impact, a value of 1 slips by the full kline range when the order is the entire volume.
+	Coefficient decimal.Decimal
+
+	// MaxPct caps the slippage as a fraction of the price, ignored if zero.
+	MaxPct decimal.Decimal
+}
+
+// NewImpactCoster creates a new ImpactCoster.
+func NewImpactCoster(coefficient decimal.Decimal) *ImpactCoster {
+	return &ImpactCoster{
+		Coefficient: coefficient,
+	}
+}
+
+// Slippage returns the square-root market impact of filling the remaining size of the order.
+// The Simulator caps the order size at the size of each fill, so a partial fill is charged only for its own size.
+func (c *ImpactCoster) Slippage(order broker.Order, price decimal.Decimal, kline market.Kline) decimal.Decimal {
+	size := order.Size.Sub(order.FilledSize)
+	if !size.IsPositive() {
+		return decimal.Zero
+	}
+
+	participation := 1.0
+	if kline.Volume > 0 {
+		participation = math.Min(size.InexactFloat64()/kline.Volume, 1)
+	}
+
+	slippage := kline.H.Sub(kline.L).Mul(c.Coefficient).Mul(dec.New(math.Sqrt(participation)))
+	if c.MaxPct.IsPositive() {
+		slippage = decimal.Min(slippage, price.Mul(c.MaxPct))
+	}
+	return slippage
+}
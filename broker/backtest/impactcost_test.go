@@ -0,0 +1,117 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestImpactCosterSlippage(t *testing.T) {
+	kline := market.Kline{O: dec.New(100), H: dec.New(110), L: dec.New(90), C: dec.New(100), Volume: 400}
+
+	tests := []struct {
+		name  string
+		cost  ImpactCoster
+		order broker.Order
+		kline market.Kline
+		want  decimal.Decimal
+	}{
+		{
+			name:  "quarter of volume",
+			cost:  ImpactCoster{Coefficient: dec.New(0.5)},
+			order: broker.Order{Size: dec.New(100)},
+			kline: kline,
+			want:  dec.New(5),
+		},
+		{
+			name:  "remaining size of partial fill",
+			cost:  ImpactCoster{Coefficient: dec.New(0.5)},
+			order: broker.Order{Size: dec.New(116), FilledSize: dec.New(100)},
+			kline: kline,
+			want:  dec.New(2),
+		},
+		{
+			name:  "participation capped at volume",
+			cost:  ImpactCoster{Coefficient: dec.New(0.5)},
+			order: broker.Order{Size: dec.New(1000)},
+			kline: kline,
+			want:  dec.New(10),
+		},
+		{
+			name:  "no volume",
+			cost:  ImpactCoster{Coefficient: dec.New(1)},
+			order: broker.Order{Size: dec.New(1)},
+			kline: market.Kline{H: dec.New(110), L: dec.New(90)},
+			want:  dec.New(20),
+		},
+		{
+			name:  "capped by max pct",
+			cost:  ImpactCoster{Coefficient: dec.New(0.5), MaxPct: dec.New(0.01)},
+			order: broker.Order{Size: dec.New(100)},
+			kline: kline,
+			want:  dec.New(1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := tt.cost.Slippage(tt.order, dec.New(100), tt.kline)
+			assert.True(t, act.Equal(tt.want), act.String())
+		})
+	}
+}
+
+func TestImpactCosterVolatileBar(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	prices := []market.Kline{
+		{Start: start, O: dec.New(100), H: dec.New(101), L: dec.New(99), C: dec.New(100), Volume: 100},
+		{Start: start.Add(time.Hour), O: dec.New(100), H: dec.New(120), L: dec.New(80), C: dec.New(100), Volume: 100},
+	}
+
+	var fills []decimal.Decimal
+	for _, price := range prices {
+		dealer := NewDealerWithCost(NewImpactCoster(dec.New(1)))
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		order, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(25)))
+		assert.NoError(t, err)
+		fills = append(fills, order.FilledPrice)
+	}
+
+	assert.True(t, fills[0].Equal(dec.New(101)), fills[0].String())
+	assert.True(t, fills[1].Equal(dec.New(120)), fills[1].String())
+}
+
+func TestImpactCosterPartialFill(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	prices := []market.Kline{
+		{Start: start, O: dec.New(100), H: dec.New(120), L: dec.New(80), C: dec.New(100), Volume: 100},
+		{Start: start.Add(time.Hour), O: dec.New(100), H: dec.New(120), L: dec.New(80), C: dec.New(100), Volume: 100},
+	}
+
+	dealer := NewDealerWithCost(NewImpactCoster(dec.New(1)))
+	dealer.SetLiquidity(NewVolumeLiquidity(dec.New(0.25)))
+	for i, price := range prices {
+		assert.NoError(t, dealer.ReceivePrice(context.Background(), price))
+		if i == 0 {
+			_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.Asset{}, broker.Buy, dec.New(100)))
+			assert.NoError(t, err)
+		}
+	}
+
+	// Each fill takes a quarter of the volume and slips by half the kline range, not by the unfilled remainder
+	fills, _, err := dealer.ListFills(context.Background(), nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, fills, 2)
+	for _, fill := range fills {
+		assert.True(t, fill.Size.Equal(dec.New(25)), fill.Size.String())
+		assert.True(t, fill.Price.Equal(dec.New(120)), fill.Price.String())
+	}
+}
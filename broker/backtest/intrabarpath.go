@@ -0,0 +1,69 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+// IntrabarPath is a model of the sequence of prices traded within a kline.
+// Used by Simulator to determine the order in which resting orders are triggered in the same kline.
+// Param side is the side of the open position in the kline asset, or 0 if there is no open position.
+type IntrabarPath func(price market.Kline, side broker.OrderSide) []decimal.Decimal
+
+// IntrabarPaths maps a config name to an IntrabarPath model.
+var IntrabarPaths = map[string]IntrabarPath{
+	"ohlc":        PathOHLC,
+	"olhc":        PathOLHC,
+	"nearest":     PathNearestExtreme,
+	"pessimistic": PathPessimistic,
+}
+
+// PathOHLC assumes the high is traded before the low.
+func PathOHLC(price market.Kline, side broker.OrderSide) []decimal.Decimal {
+	return []decimal.Decimal{price.O, price.H, price.L, price.C}
+}
+
+// PathOLHC assumes the low is traded before the high.
+func PathOLHC(price market.Kline, side broker.OrderSide) []decimal.Decimal {
+	return []decimal.Decimal{price.O, price.L, price.H, price.C}
+}
+
+// PathNearestExtreme assumes the extreme nearest to the open is traded first.
+func PathNearestExtreme(price market.Kline, side broker.OrderSide) []decimal.Decimal {
+	if price.H.Sub(price.O).LessThanOrEqual(price.O.Sub(price.L)) {
+		return PathOHLC(price, side)
+	}
+	return PathOLHC(price, side)
+}
+
+// PathPessimistic assumes the extreme adverse to the open position is traded first,
+// so that a stop loss is triggered before a take profit in the same kline.
+func PathPessimistic(price market.Kline, side broker.OrderSide) []decimal.Decimal {
+	if side == broker.Sell {
+		return PathOHLC(price, side)
+	}
+	return PathOLHC(price, side)
+}
+
+// pathDistance returns how far along the path the given price is first traded,
+// where each segment between two path prices has a length of 1.
+// Returns false if the price is not traded on the path.
+func pathDistance(path []decimal.Decimal, price decimal.Decimal) (decimal.Decimal, bool) {
+	for i := 0; i < len(path)-1; i++ {
+		from, to := path[i], path[i+1]
+		low, high := decimal.Min(from, to), decimal.Max(from, to)
+		if price.LessThan(low) || price.GreaterThan(high) {
+			continue
+		}
+		if from.Equal(to) {
+			return decimal.NewFromInt(int64(i)), true
+		}
+		frac := price.Sub(from).Div(to.Sub(from)).Abs()
+		return decimal.NewFromInt(int64(i)).Add(frac), true
+	}
+	return decimal.Zero, false
+}
@@ -0,0 +1,117 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestIntrabarPath(t *testing.T) {
+	upper := market.Kline{O: dec.New(10), H: dec.New(11), L: dec.New(5), C: dec.New(8)}
+	lower := market.Kline{O: dec.New(10), H: dec.New(15), L: dec.New(9), C: dec.New(12)}
+	ohlc := func(k market.Kline) []decimal.Decimal { return []decimal.Decimal{k.O, k.H, k.L, k.C} }
+	olhc := func(k market.Kline) []decimal.Decimal { return []decimal.Decimal{k.O, k.L, k.H, k.C} }
+
+	tests := []struct {
+		name      string
+		givePath  IntrabarPath
+		givePrice market.Kline
+		giveSide  broker.OrderSide
+		want      []decimal.Decimal
+	}{
+		{
+			name:      "ohlc",
+			givePath:  PathOHLC,
+			givePrice: lower,
+			want:      ohlc(lower),
+		},
+		{
+			name:      "olhc",
+			givePath:  PathOLHC,
+			givePrice: upper,
+			want:      olhc(upper),
+		},
+		{
+			name:      "nearest extreme is high",
+			givePath:  PathNearestExtreme,
+			givePrice: upper,
+			want:      ohlc(upper),
+		},
+		{
+			name:      "nearest extreme is low",
+			givePath:  PathNearestExtreme,
+			givePrice: lower,
+			want:      olhc(lower),
+		},
+		{
+			name:      "pessimistic long",
+			givePath:  PathPessimistic,
+			givePrice: upper,
+			giveSide:  broker.Buy,
+			want:      olhc(upper),
+		},
+		{
+			name:      "pessimistic short",
+			givePath:  PathPessimistic,
+			givePrice: lower,
+			giveSide:  broker.Sell,
+			want:      ohlc(lower),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := tt.givePath(tt.givePrice, tt.giveSide)
+			assert.Equal(t, tt.want, act)
+		})
+	}
+}
+
+func TestPathDistance(t *testing.T) {
+	path := []decimal.Decimal{dec.New(10), dec.New(14), dec.New(6), dec.New(8)}
+
+	tests := []struct {
+		name       string
+		givePrice  decimal.Decimal
+		wantDist   decimal.Decimal
+		wantTraded bool
+	}{
+		{
+			name:       "open",
+			givePrice:  dec.New(10),
+			wantDist:   dec.New(0),
+			wantTraded: true,
+		},
+		{
+			name:       "first segment",
+			givePrice:  dec.New(12),
+			wantDist:   dec.New(0.5),
+			wantTraded: true,
+		},
+		{
+			name:       "second segment",
+			givePrice:  dec.New(7),
+			wantDist:   dec.New(1.875),
+			wantTraded: true,
+		},
+		{
+			name:       "not traded",
+			givePrice:  dec.New(20),
+			wantDist:   dec.New(0),
+			wantTraded: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dist, traded := pathDistance(path, tt.givePrice)
+			assert.Equal(t, tt.wantTraded, traded)
+			assert.True(t, dist.Equal(tt.wantDist), dist.String())
+		})
+	}
+}
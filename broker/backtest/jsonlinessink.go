@@ -0,0 +1,38 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"encoding/json"
+	"io"
+)
+
+var _ Subscriber = (*JSONLinesSink)(nil)
+
+// JSONLinesSink is a Subscriber that writes each event as a line of JSON.
+type JSONLinesSink struct {
+	enc *json.Encoder
+	err error
+}
+
+// NewJSONLinesSink creates a new JSONLinesSink writing to the given writer.
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// ReceiveEvent writes the event as a line of JSON.
+// Events are dropped after the first write error, which is returned by Err.
+func (s *JSONLinesSink) ReceiveEvent(event Event) {
+	if s.err != nil {
+		return
+	}
+	s.err = s.enc.Encode(event)
+}
+
+// Err returns the first error encountered writing events.
+func (s *JSONLinesSink) Err() error {
+	return s.err
+}
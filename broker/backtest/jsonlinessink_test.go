@@ -0,0 +1,39 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/gou/dec"
+)
+
+type errWriter struct{}
+
+func (w errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestJSONLinesSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONLinesSink(&buf)
+	sink.ReceiveEvent(Event{Type: EquityMarked, Time: time.UnixMilli(0).UTC(), Amount: dec.New(10)})
+	sink.ReceiveEvent(Event{Type: OrderRejected, Time: time.UnixMilli(0).UTC(), Order: &broker.Order{ID: "1"}, Reason: "insufficient margin"})
+	assert.NoError(t, sink.Err())
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 2)
+	assert.Equal(t, `{"type":"EquityMarked","time":"1970-01-01T00:00:00Z","amount":"10"}`, string(lines[0]))
+	assert.Contains(t, string(lines[1]), `"type":"OrderRejected"`)
+	assert.Contains(t, string(lines[1]), `"reason":"insufficient margin"`)
+
+	sink = NewJSONLinesSink(errWriter{})
+	sink.ReceiveEvent(Event{Type: EquityMarked})
+	assert.Error(t, sink.Err())
+}
@@ -0,0 +1,149 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"encoding/json"
+	"math/rand"
+	"time"
+)
+
+// Latency is a model of the delay between an order being placed and becoming live on the market.
+type Latency interface {
+	Delay() time.Duration
+}
+
+var _ Latency = (*FixedLatency)(nil)
+var _ Latency = (*UniformLatency)(nil)
+var _ Latency = (*EmpiricalLatency)(nil)
+
+// FixedLatency delays every order by the same duration.
+type FixedLatency struct {
+	Duration time.Duration
+}
+
+// NewFixedLatency creates a new FixedLatency.
+func NewFixedLatency(duration time.Duration) *FixedLatency {
+	return &FixedLatency{Duration: duration}
+}
+
+// Delay returns the fixed duration.
+func (l *FixedLatency) Delay() time.Duration {
+	return l.Duration
+}
+
+// UniformLatency delays orders by a duration drawn uniformly from [Min, Max].
+type UniformLatency struct {
+	Min time.Duration
+	Max time.Duration
+
+	// Seed makes the sequence of delays repeatable between backtests.
+	Seed int64
+
+	rand  *rand.Rand
+	draws int
+}
+
+// NewUniformLatency creates a new UniformLatency.
+func NewUniformLatency(min, max time.Duration, seed int64) *UniformLatency {
+	return &UniformLatency{
+		Min:  min,
+		Max:  max,
+		Seed: seed,
+	}
+}
+
+// Delay returns a random duration between Min and Max inclusive.
+func (l *UniformLatency) Delay() time.Duration {
+	if l.Max <= l.Min {
+		return l.Min
+	}
+	if l.rand == nil {
+		l.rand = rand.New(rand.NewSource(l.Seed))
+	}
+	l.draws++
+	return l.Min + time.Duration(l.rand.Int63n(int64(l.Max-l.Min)+1))
+}
+
+// uniformLatencyState is the serializable state of a UniformLatency.
+type uniformLatencyState struct {
+	Min   time.Duration
+	Max   time.Duration
+	Seed  int64
+	Draws int
+}
+
+// MarshalJSON includes the number of delays drawn so that a Simulator snapshot can be restored.
+func (l *UniformLatency) MarshalJSON() ([]byte, error) {
+	return json.Marshal(uniformLatencyState{Min: l.Min, Max: l.Max, Seed: l.Seed, Draws: l.draws})
+}
+
+// UnmarshalJSON restores the state output by MarshalJSON, replaying the drawn delays from the seed.
+func (l *UniformLatency) UnmarshalJSON(data []byte) error {
+	var state uniformLatencyState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	*l = UniformLatency{Min: state.Min, Max: state.Max, Seed: state.Seed}
+	for i := 0; i < state.Draws; i++ {
+		l.Delay()
+	}
+	return nil
+}
+
+// EmpiricalLatency delays orders by a duration sampled from observed latencies.
+type EmpiricalLatency struct {
+	Samples []time.Duration
+
+	// Seed makes the sequence of delays repeatable between backtests.
+	Seed int64
+
+	rand  *rand.Rand
+	draws int
+}
+
+// NewEmpiricalLatency creates a new EmpiricalLatency.
+func NewEmpiricalLatency(samples []time.Duration, seed int64) *EmpiricalLatency {
+	return &EmpiricalLatency{
+		Samples: samples,
+		Seed:    seed,
+	}
+}
+
+// Delay returns a randomly selected sample, or zero if there are no samples.
+func (l *EmpiricalLatency) Delay() time.Duration {
+	if len(l.Samples) == 0 {
+		return 0
+	}
+	if l.rand == nil {
+		l.rand = rand.New(rand.NewSource(l.Seed))
+	}
+	l.draws++
+	return l.Samples[l.rand.Intn(len(l.Samples))]
+}
+
+// empiricalLatencyState is the serializable state of an EmpiricalLatency.
+type empiricalLatencyState struct {
+	Samples []time.Duration
+	Seed    int64
+	Draws   int
+}
+
+// MarshalJSON includes the number of delays drawn so that a Simulator snapshot can be restored.
+func (l *EmpiricalLatency) MarshalJSON() ([]byte, error) {
+	return json.Marshal(empiricalLatencyState{Samples: l.Samples, Seed: l.Seed, Draws: l.draws})
+}
+
+// UnmarshalJSON restores the state output by MarshalJSON, replaying the drawn delays from the seed.
+func (l *EmpiricalLatency) UnmarshalJSON(data []byte) error {
+	var state empiricalLatencyState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	*l = EmpiricalLatency{Samples: state.Samples, Seed: state.Seed}
+	for i := 0; i < state.Draws; i++ {
+		l.Delay()
+	}
+	return nil
+}
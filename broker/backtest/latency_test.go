@@ -0,0 +1,82 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyDelay(t *testing.T) {
+	samples := []time.Duration{10 * time.Millisecond, 50 * time.Millisecond}
+
+	tests := []struct {
+		name    string
+		give    Latency
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{
+			name:    "fixed",
+			give:    NewFixedLatency(20 * time.Millisecond),
+			wantMin: 20 * time.Millisecond,
+			wantMax: 20 * time.Millisecond,
+		},
+		{
+			name:    "uniform",
+			give:    NewUniformLatency(10*time.Millisecond, 30*time.Millisecond, 1),
+			wantMin: 10 * time.Millisecond,
+			wantMax: 30 * time.Millisecond,
+		},
+		{
+			name:    "uniform with max less than min",
+			give:    NewUniformLatency(10*time.Millisecond, 5*time.Millisecond, 1),
+			wantMin: 10 * time.Millisecond,
+			wantMax: 10 * time.Millisecond,
+		},
+		{
+			name:    "empirical",
+			give:    NewEmpiricalLatency(samples, 1),
+			wantMin: 10 * time.Millisecond,
+			wantMax: 50 * time.Millisecond,
+		},
+		{
+			name:    "uniform literal",
+			give:    &UniformLatency{Min: 10 * time.Millisecond, Max: 30 * time.Millisecond},
+			wantMin: 10 * time.Millisecond,
+			wantMax: 30 * time.Millisecond,
+		},
+		{
+			name:    "empirical literal",
+			give:    &EmpiricalLatency{Samples: samples},
+			wantMin: 10 * time.Millisecond,
+			wantMax: 50 * time.Millisecond,
+		},
+		{
+			name:    "empirical without samples",
+			give:    NewEmpiricalLatency(nil, 1),
+			wantMin: 0,
+			wantMax: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				act := tt.give.Delay()
+				assert.GreaterOrEqual(t, act, tt.wantMin)
+				assert.LessOrEqual(t, act, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestLatencySeed(t *testing.T) {
+	give := NewUniformLatency(0, time.Second, 1)
+	want := &UniformLatency{Max: time.Second, Seed: 1}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, want.Delay(), give.Delay())
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+// Liquidity is a liquidity model used by a dealer to limit the size of an order fill.
+type Liquidity interface {
+	// Available returns the maximum size of the order that can be filled against the given price.
+	Available(order broker.Order, price market.Kline) decimal.Decimal
+}
@@ -0,0 +1,48 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"sort"
+	"time"
+
+	"github.com/thecolngroup/alphakit/market"
+)
+
+// LowerTimeframe is a finer grained price feed used by Simulator to match orders within the klines of a coarser feed.
+// For example 1m klines can be used to resolve the sequence of fills within 1h strategy klines.
+type LowerTimeframe struct {
+	prices   []market.Kline
+	interval time.Duration
+	cursor   int
+}
+
+// NewLowerTimeframe creates a new LowerTimeframe feed.
+// Param prices is the finer grained feed and is sorted by start time.
+// Param interval is the duration of a kline in the coarser feed.
+func NewLowerTimeframe(prices []market.Kline, interval time.Duration) *LowerTimeframe {
+	sorted := make([]market.Kline, len(prices))
+	copy(sorted, prices)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+	return &LowerTimeframe{
+		prices:   sorted,
+		interval: interval,
+	}
+}
+
+// Window returns the klines that start within the coarse kline beginning at the given start time.
+// The feed is consumed forward only, calls must be made with increasing start times.
+func (f *LowerTimeframe) Window(start time.Time) []market.Kline {
+	for f.cursor < len(f.prices) && f.prices[f.cursor].Start.Before(start) {
+		f.cursor++
+	}
+	end := start.Add(f.interval)
+	from := f.cursor
+	for f.cursor < len(f.prices) && f.prices[f.cursor].Start.Before(end) {
+		f.cursor++
+	}
+	return f.prices[from:f.cursor]
+}
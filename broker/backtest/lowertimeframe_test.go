@@ -0,0 +1,52 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+func TestLowerTimeframeWindow(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	var prices []market.Kline
+	for i := 5; i >= 0; i-- {
+		prices = append(prices, market.Kline{Start: start.Add(time.Duration(i) * 30 * time.Minute)})
+	}
+	feed := NewLowerTimeframe(prices, time.Hour)
+
+	tests := []struct {
+		name      string
+		giveStart time.Time
+		want      []time.Time
+	}{
+		{
+			name:      "first window",
+			giveStart: start,
+			want:      []time.Time{start, start.Add(30 * time.Minute)},
+		},
+		{
+			name:      "skip missing window",
+			giveStart: start.Add(2 * time.Hour),
+			want:      []time.Time{start.Add(2 * time.Hour), start.Add(150 * time.Minute)},
+		},
+		{
+			name:      "end of feed",
+			giveStart: start.Add(3 * time.Hour),
+			want:      nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var act []time.Time
+			for _, price := range feed.Window(tt.giveStart) {
+				act = append(act, price.Start)
+			}
+			assert.Equal(t, tt.want, act)
+		})
+	}
+}
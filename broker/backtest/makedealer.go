@@ -4,7 +4,12 @@
 package backtest
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
 	"github.com/thecolngroup/gou/conv"
 	"github.com/thecolngroup/gou/dec"
 )
@@ -14,11 +19,80 @@ func MakeDealerFromConfig(config map[string]any) (broker.SimulatedDealer, error)
 	dealer := NewDealer()
 
 	dealer.simulator.SetInitialCapital(dec.New(config["initialcapital"].(float64)))
-	dealer.simulator.cost = &PerpCoster{
-		SpreadPct:      dec.New(conv.ToFloat(config["spreadpct"])),
-		SlippagePct:    dec.New(conv.ToFloat(config["slippagepct"])),
-		TransactionPct: dec.New(conv.ToFloat(config["transactionpct"])),
-		FundingHourPct: dec.New(conv.ToFloat(config["fundinghourpct"])),
+	cost, err := MakeCosterFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dealer.simulator.cost = cost
+
+	// Margin model is optional and enabled by setting a max leverage
+	if _, ok := config["maxleverage"]; ok {
+		dealer.simulator.SetMargin(NewMargin(MarginRule{
+			MaxLeverage:          dec.New(conv.ToFloat(config["maxleverage"])),
+			InitialMarginPct:     dec.New(conv.ToFloat(config["initialmarginpct"])),
+			MaintenanceMarginPct: dec.New(conv.ToFloat(config["maintenancemarginpct"])),
+			LiquidationFeePct:    dec.New(conv.ToFloat(config["liquidationfeepct"])),
+		}))
+	}
+
+	// Liquidity model is optional and enabled by setting the max fraction of kline volume an order can take
+	if _, ok := config["maxvolumepct"]; ok {
+		dealer.SetLiquidity(NewVolumeLiquidity(dec.New(conv.ToFloat(config["maxvolumepct"]))))
+	}
+
+	// Latency is optional: a fixed delay, uniform between min and max, or sampled from observed delays
+	ms := func(v any) time.Duration { return time.Duration(conv.ToFloat(v) * float64(time.Millisecond)) }
+	seed := int64(conv.ToFloat(config["latencyseed"]))
+	switch {
+	case config["latencysamplesms"] != nil:
+		var samples []time.Duration
+		for _, v := range config["latencysamplesms"].([]any) {
+			samples = append(samples, ms(v))
+		}
+		dealer.simulator.SetLatency(NewEmpiricalLatency(samples, seed))
+	case config["latencymaxms"] != nil:
+		dealer.simulator.SetLatency(NewUniformLatency(ms(config["latencyms"]), ms(config["latencymaxms"]), seed))
+	case config["latencyms"] != nil:
+		dealer.simulator.SetLatency(NewFixedLatency(ms(config["latencyms"])))
+	}
+
+	// Multiple currencies are optional and enabled by setting a base currency,
+	// the currency pair of each asset is given as a table of symbol, base and quote
+	if base, ok := config["basecurrency"]; ok {
+		converter := market.NewConverter()
+		if pairs, ok := config["currencypairs"].([]any); ok {
+			for _, v := range pairs {
+				pair := v.(map[string]any)
+				converter.SetPair(market.NewAsset(conv.ToString(configValue(pair, "symbol"))), market.CurrencyPair{
+					Base:  conv.ToString(configValue(pair, "base")),
+					Quote: conv.ToString(configValue(pair, "quote")),
+				})
+			}
+		}
+		dealer.simulator.SetCurrency(conv.ToString(base), converter)
+	}
+
+	// Position mode is optional and selected by name
+	if name, ok := config["positionmode"]; ok {
+		mode, ok := PositionModes[strings.ToLower(conv.ToString(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown position mode: %v", name)
+		}
+		dealer.simulator.SetPositionMode(mode)
+	}
+
+	// Reversal of a position by a single order is optional
+	if reversal, ok := config["reversal"].(bool); ok {
+		dealer.simulator.SetReversal(reversal)
+	}
+
+	// Intrabar path is optional and selected by name
+	if name, ok := config["intrabarpath"]; ok {
+		path, ok := IntrabarPaths[strings.ToLower(conv.ToString(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown intrabar path: %v", name)
+		}
+		dealer.simulator.SetIntrabarPath(path)
 	}
 
 	return dealer, nil
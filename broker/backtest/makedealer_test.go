@@ -0,0 +1,168 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestMakeDealerFromConfigTieredFees(t *testing.T) {
+	config := map[string]any{
+		"initialcapital": 1000.0,
+		"spreadpct":      0.001,
+		"makerpct":       0.0002,
+		"takerpct":       0.0005,
+		"feewindowdays":  7.0,
+		"feetiers": []any{
+			map[string]any{"minNotional": 100000.0, "makerPct": -0.0001, "takerPct": 0.0004},
+		},
+	}
+
+	dealer, err := MakeDealerFromConfig(config)
+	assert.NoError(t, err)
+
+	cost, ok := dealer.(*Dealer).simulator.cost.(*TieredFeeCoster)
+	assert.True(t, ok)
+	assert.Len(t, cost.Tiers, 2)
+	assert.True(t, cost.Tiers[1].MinNotional.Equal(dec.New(100000)))
+	assert.True(t, cost.Tiers[1].MakerPct.Equal(dec.New(-0.0001)))
+	assert.True(t, cost.SpreadPct.Equal(dec.New(0.001)))
+	assert.Equal(t, 7*24*time.Hour, cost.Window)
+}
+
+func TestMakeDealerFromConfigCurrencies(t *testing.T) {
+	config := map[string]any{
+		"initialcapital": 1000.0,
+		"basecurrency":   "USDT",
+		"currencypairs": []any{
+			map[string]any{"symbol": "BTCEUR", "base": "BTC", "quote": "EUR"},
+			map[string]any{"Symbol": "EURUSDT", "Base": "EUR", "Quote": "USDT"},
+		},
+	}
+
+	dealer, err := MakeDealerFromConfig(config)
+	assert.NoError(t, err)
+
+	sim := dealer.(*Dealer).simulator
+	assert.Equal(t, "USDT", sim.currency)
+	pair, ok := sim.converter.Pair(market.NewAsset("EURUSDT"))
+	assert.True(t, ok)
+	assert.Equal(t, market.CurrencyPair{Base: "EUR", Quote: "USDT"}, pair)
+	assert.Equal(t, "EUR", sim.quoteCurrency(market.NewAsset("BTCEUR")))
+}
+
+func TestMakeDealerFromConfigPositionMode(t *testing.T) {
+	dealer, err := MakeDealerFromConfig(map[string]any{"initialcapital": 1000.0, "positionmode": "Hedge"})
+	assert.NoError(t, err)
+	assert.Equal(t, Hedge, dealer.(*Dealer).simulator.mode)
+
+	_, err = MakeDealerFromConfig(map[string]any{"initialcapital": 1000.0, "positionmode": "netted"})
+	assert.Error(t, err)
+}
+
+func TestMakeDealerFromConfigLiquidity(t *testing.T) {
+	dealer, err := MakeDealerFromConfig(map[string]any{"initialcapital": 1000.0, "maxvolumepct": 0.1})
+	assert.NoError(t, err)
+	liquidity, ok := dealer.(*Dealer).simulator.liquidity.(*VolumeLiquidity)
+	assert.True(t, ok)
+	assert.True(t, liquidity.VolumePct.Equal(dec.New(0.1)))
+
+	dealer, err = MakeDealerFromConfig(map[string]any{"initialcapital": 1000.0})
+	assert.NoError(t, err)
+	assert.Nil(t, dealer.(*Dealer).simulator.liquidity)
+}
+
+func TestMakeCosterFromConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		give    map[string]any
+		want    Coster
+		wantErr bool
+	}{
+		{
+			name: "default perp",
+			give: map[string]any{"spreadpct": 0.001},
+			want: &PerpCoster{SpreadPct: dec.New(0.001)},
+		},
+		{
+			name: "single name",
+			give: map[string]any{"coster": "Borrow", "borrowannualpct": 0.03},
+			want: &BorrowCoster{AnnualPct: dec.New(0.03)},
+		},
+		{
+			name: "equity costs",
+			give: map[string]any{
+				"coster":             []any{"commission", "regulatoryfee"},
+				"commissionperunit":  0.005,
+				"commissionmin":      1.0,
+				"commissionmaxpct":   0.01,
+				"exchangefeeperunit": 0.003,
+				"sellfeepct":         0.0000278,
+			},
+			want: NewCompositeCoster(
+				&CommissionCoster{PerUnit: dec.New(0.005), Min: dec.New(1), Max: dec.New(0), MaxPct: dec.New(0.01)},
+				&RegulatoryFeeCoster{ExchangePerUnit: dec.New(0.003), SellNotionalPct: dec.New(0.0000278), SellPerUnit: dec.New(0), SellPerUnitMax: dec.New(0)},
+			),
+		},
+		{
+			name: "impact slippage",
+			give: map[string]any{"coster": "impact", "impactcoef": 0.5, "impactmaxpct": 0.02},
+			want: &ImpactCoster{Coefficient: dec.New(0.5), MaxPct: dec.New(0.02)},
+		},
+		{
+			name: "tiered fee embeds perp costs",
+			give: map[string]any{"coster": "tieredfee", "spreadpct": 0.001, "fundinghourpct": 0.0001, "takerpct": 0.0005},
+			want: &TieredFeeCoster{
+				PerpCoster: PerpCoster{SpreadPct: dec.New(0.001), SlippagePct: dec.New(0), TransactionPct: dec.New(0), FundingHourPct: dec.New(0.0001)},
+				Tiers:      []FeeTier{{MakerPct: dec.New(0), TakerPct: dec.New(0.0005)}},
+				Window:     DefaultFeeWindow,
+			},
+		},
+		{
+			name:    "perp and tiered fee both cost spread",
+			give:    map[string]any{"coster": []any{"perp", "tieredfee"}, "takerpct": 0.0005},
+			wantErr: true,
+		},
+		{
+			name:    "tier keys ignored by selected coster",
+			give:    map[string]any{"coster": []any{"perp", "borrow"}, "takerpct": 0.0005},
+			wantErr: true,
+		},
+		{
+			name:    "funding rate path ignored by selected coster",
+			give:    map[string]any{"coster": "perp", "fundingratepath": "rates.csv"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown name",
+			give:    map[string]any{"coster": "free"},
+			wantErr: true,
+		},
+		{
+			name:    "funding rate without path",
+			give:    map[string]any{"coster": "fundingrate"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := MakeCosterFromConfig(tt.give)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			want, _ := json.Marshal(tt.want)
+			got, _ := json.Marshal(act)
+			assert.IsType(t, tt.want, act)
+			assert.JSONEq(t, string(want), string(got))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+// MarginRule defines the margin requirements for trading an asset with leverage.
+type MarginRule struct {
+
+	// MaxLeverage is the maximum notional exposure as a multiple of margin, e.g. 10 is 10x.
+	MaxLeverage decimal.Decimal
+
+	// InitialMarginPct is the fraction of notional required as margin to open a position.
+	// The effective initial margin rate is the greater of InitialMarginPct and 1 / MaxLeverage.
+	InitialMarginPct decimal.Decimal
+
+	// MaintenanceMarginPct is the fraction of notional below which equity triggers a liquidation.
+	MaintenanceMarginPct decimal.Decimal
+
+	// LiquidationFeePct is the fraction of notional charged when a position is liquidated.
+	LiquidationFeePct decimal.Decimal
+}
+
+// InitialRate returns the effective initial margin rate.
+func (r MarginRule) InitialRate() decimal.Decimal {
+	rate := r.InitialMarginPct
+	if r.MaxLeverage.IsPositive() {
+		rate = decimal.Max(rate, dec.New(1).Div(r.MaxLeverage))
+	}
+	return rate
+}
+
+// Margin is a cross margin model used by a dealer to limit leverage and liquidate under-margined positions.
+// All open positions share the account equity as collateral.
+type Margin struct {
+
+	// Default is the rule applied to any asset without a rule in Assets.
+	Default MarginRule
+
+	// Assets holds per asset margin rules.
+	Assets map[market.Asset]MarginRule
+}
+
+// NewMargin creates a new Margin with the given default rule.
+func NewMargin(rule MarginRule) *Margin {
+	return &Margin{
+		Default: rule,
+		Assets:  make(map[market.Asset]MarginRule),
+	}
+}
+
+// Rule returns the margin rule for the asset.
+func (m *Margin) Rule(asset market.Asset) MarginRule {
+	if rule, ok := m.Assets[asset]; ok {
+		return rule
+	}
+	return m.Default
+}
+
+// liquidationPrice returns the mark price at which the position equity falls to the maintenance margin.
+// Param excess is the account equity less the maintenance margin of all other positions.
+func liquidationPrice(position broker.Position, maintenancePct, excess decimal.Decimal) decimal.Decimal {
+	if !position.Size.IsPositive() {
+		return decimal.Zero
+	}
+	one := dec.New(1)
+	switch position.Side {
+	case broker.Buy:
+		return position.Cost.Sub(excess).Div(position.Size.Mul(one.Sub(maintenancePct)))
+	case broker.Sell:
+		return position.Cost.Add(excess).Div(position.Size.Mul(one.Add(maintenancePct)))
+	}
+	return decimal.Zero
+}
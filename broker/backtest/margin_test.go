@@ -0,0 +1,75 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestMarginRule_InitialRate(t *testing.T) {
+	tests := []struct {
+		name string
+		give MarginRule
+		want decimal.Decimal
+	}{
+		{
+			name: "leverage is greater",
+			give: MarginRule{MaxLeverage: dec.New(5), InitialMarginPct: dec.New(0.1)},
+			want: dec.New(0.2),
+		},
+		{
+			name: "initial margin is greater",
+			give: MarginRule{MaxLeverage: dec.New(20), InitialMarginPct: dec.New(0.1)},
+			want: dec.New(0.1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.give.InitialRate().Equal(tt.want))
+		})
+	}
+}
+
+func TestMargin_Rule(t *testing.T) {
+	btc := market.NewAsset("BTCUSD")
+	margin := NewMargin(MarginRule{MaxLeverage: dec.New(2)})
+	margin.Assets[btc] = MarginRule{MaxLeverage: dec.New(10)}
+
+	assert.True(t, margin.Rule(btc).MaxLeverage.Equal(dec.New(10)))
+	assert.True(t, margin.Rule(market.NewAsset("ETHUSD")).MaxLeverage.Equal(dec.New(2)))
+}
+
+func TestLiquidationPrice(t *testing.T) {
+	tests := []struct {
+		name         string
+		givePosition broker.Position
+		giveExcess   decimal.Decimal
+		want         decimal.Decimal
+	}{
+		{
+			name:         "long",
+			givePosition: broker.Position{Side: broker.Buy, Size: dec.New(10), Cost: dec.New(1000)},
+			giveExcess:   dec.New(100),
+			want:         dec.New(90),
+		},
+		{
+			name:         "short",
+			givePosition: broker.Position{Side: broker.Sell, Size: dec.New(10), Cost: dec.New(1000)},
+			giveExcess:   dec.New(100),
+			want:         dec.New(110),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := liquidationPrice(tt.givePosition, decimal.Zero, tt.giveExcess)
+			assert.True(t, act.Equal(tt.want), act.String())
+		})
+	}
+}
@@ -4,11 +4,13 @@
 package backtest
 
 import (
+	"encoding/json"
 	"math"
 	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
 	"github.com/thecolngroup/gou/dec"
 )
 
@@ -21,7 +23,7 @@ type PerpCoster struct {
 	TransactionPct decimal.Decimal
 	FundingHourPct decimal.Decimal
 
-	lastFundingHour float64
+	lastFundingHours map[broker.DealID]float64
 }
 
 // NewPerpCoster creates a new PerpCoster.
@@ -31,7 +33,7 @@ func NewPerpCoster() *PerpCoster {
 
 // Slippage returns the cost of slippage incurred by an order.
 // Slippage is a fraction of the order price.
-func (c *PerpCoster) Slippage(price decimal.Decimal) decimal.Decimal {
+func (c *PerpCoster) Slippage(order broker.Order, price decimal.Decimal, kline market.Kline) decimal.Decimal {
 	return price.Mul(c.SlippagePct)
 }
 
@@ -50,6 +52,9 @@ func (c *PerpCoster) Transaction(order broker.Order) decimal.Decimal {
 }
 
 // Funding returns the funding fee for a position, calculated on an hourly basis.
+// Funding intervals are tracked separately for each position from the elapsed hours when it was opened,
+// so that the long and short positions of an asset in hedge mode are each charged.
+// A position not yet seen by openFunding is funded from the elapsed hours of its first call.
 func (c *PerpCoster) Funding(position broker.Position, price decimal.Decimal, elapsed time.Duration) decimal.Decimal {
 
 	if position.State() != broker.OrderOpen {
@@ -57,15 +62,66 @@ func (c *PerpCoster) Funding(position broker.Position, price decimal.Decimal, el
 	}
 
 	hours := math.Trunc(elapsed.Hours())
-	excess := hours - c.lastFundingHour
+	last, ok := c.lastFundingHours[position.ID]
+	if !ok {
+		c.openFunding(position, elapsed)
+		return decimal.Zero
+	}
+	excess := hours - last
 
 	if excess == 0 {
 		return decimal.Zero
 	}
 
-	c.lastFundingHour = hours
+	c.lastFundingHours[position.ID] = hours
 	perHourCost := position.Size.Mul(price).Mul(c.FundingHourPct)
 	totalCost := perHourCost.Mul(dec.New(excess))
 
 	return totalCost
 }
+
+// openFunding starts the funding interval of a position at the elapsed duration when it was opened.
+func (c *PerpCoster) openFunding(position broker.Position, elapsed time.Duration) {
+	if c.lastFundingHours == nil {
+		c.lastFundingHours = make(map[broker.DealID]float64)
+	}
+	c.lastFundingHours[position.ID] = math.Trunc(elapsed.Hours())
+}
+
+// perpCosterState is the serializable state of a PerpCoster.
+type perpCosterState struct {
+	SpreadPct        decimal.Decimal
+	SlippagePct      decimal.Decimal
+	TransactionPct   decimal.Decimal
+	FundingHourPct   decimal.Decimal
+	LastFundingHours map[broker.DealID]float64
+}
+
+// MarshalJSON includes the funding state of each position so that a Simulator snapshot can be restored.
+func (c *PerpCoster) MarshalJSON() ([]byte, error) {
+	state := perpCosterState{
+		SpreadPct:        c.SpreadPct,
+		SlippagePct:      c.SlippagePct,
+		TransactionPct:   c.TransactionPct,
+		FundingHourPct:   c.FundingHourPct,
+		LastFundingHours: c.lastFundingHours,
+	}
+	return json.Marshal(state)
+}
+
+// UnmarshalJSON restores the state output by MarshalJSON.
+func (c *PerpCoster) UnmarshalJSON(data []byte) error {
+	var state perpCosterState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	c.SpreadPct = state.SpreadPct
+	c.SlippagePct = state.SlippagePct
+	c.TransactionPct = state.TransactionPct
+	c.FundingHourPct = state.FundingHourPct
+	c.lastFundingHours = state.LastFundingHours
+	if c.lastFundingHours == nil {
+		c.lastFundingHours = make(map[broker.DealID]float64)
+	}
+	return nil
+}
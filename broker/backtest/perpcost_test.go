@@ -10,6 +10,7 @@ import (
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
 	"github.com/thecolngroup/gou/dec"
 )
 
@@ -25,6 +26,7 @@ func TestPerpCostFunding(t *testing.T) {
 	}
 	givePrice := dec.New(10)
 	var giveElapsed time.Duration
+	cost.openFunding(givePosition, giveElapsed)
 
 	tests := []struct {
 		name string
@@ -69,7 +71,7 @@ func TestPerpCostSlippage(t *testing.T) {
 		SlippagePct: dec.New(0.1),
 	}
 	exp := dec.New(1)
-	act := cost.Slippage(dec.New(10))
+	act := cost.Slippage(broker.Order{}, dec.New(10), market.Kline{})
 	assert.True(t, act.Equal(exp))
 }
 
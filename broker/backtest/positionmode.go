@@ -0,0 +1,30 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+// PositionMode sets how the Simulator tracks the positions of an asset.
+type PositionMode int
+
+const (
+	// OneWay nets all orders for an asset into a single long or short position.
+	// This is the default mode.
+	OneWay PositionMode = iota
+
+	// Hedge tracks a long and a short position for an asset independently.
+	// Orders are applied to the position of their PositionSide: a Buy order opens or increases a long position
+	// and a Sell order reduces it, a Sell order opens or increases a short position and a Buy order reduces it.
+	// Orders without a PositionSide apply to the position on the same side as the order,
+	// or the opposite side if the order is ReduceOnly.
+	Hedge
+)
+
+// PositionModes maps a config name to a position mode.
+var PositionModes = map[string]PositionMode{
+	"oneway": OneWay,
+	"hedge":  Hedge,
+}
+
+func (m PositionMode) String() string {
+	return [...]string{"OneWay", "Hedge"}[m]
+}
@@ -0,0 +1,45 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+)
+
+var _ Coster = (*RegulatoryFeeCoster)(nil)
+
+// RegulatoryFeeCoster implements the Coster interface with exchange and regulatory transaction fees,
+// such as US equity exchange fees, the SEC fee charged on sale notional and the FINRA TAF charged per share sold.
+// Buy fills are charged only the exchange fee.
+type RegulatoryFeeCoster struct {
+	zeroCoster
+
+	// ExchangePerUnit is the exchange fee per share or contract filled on either side.
+	ExchangePerUnit decimal.Decimal
+
+	// SellNotionalPct is the fee charged on the notional of sell fills, e.g. SEC fee.
+	SellNotionalPct decimal.Decimal
+
+	// SellPerUnit is the fee per share or contract of sell fills, e.g. FINRA TAF.
+	SellPerUnit decimal.Decimal
+
+	// SellPerUnitMax caps the SellPerUnit fee per fill, zero is uncapped.
+	SellPerUnitMax decimal.Decimal
+}
+
+// Transaction returns the exchange fee for the fill, plus the regulatory fees for a sell fill.
+func (c *RegulatoryFeeCoster) Transaction(order broker.Order) decimal.Decimal {
+	fee := order.FilledSize.Mul(c.ExchangePerUnit)
+	if order.Side != broker.Sell {
+		return fee
+	}
+
+	fee = fee.Add(order.FilledSize.Mul(order.FilledPrice).Mul(c.SellNotionalPct))
+	perUnit := order.FilledSize.Mul(c.SellPerUnit)
+	if c.SellPerUnitMax.IsPositive() {
+		perUnit = decimal.Min(perUnit, c.SellPerUnitMax)
+	}
+	return fee.Add(perUnit)
+}
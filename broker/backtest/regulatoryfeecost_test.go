@@ -0,0 +1,50 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestRegulatoryFeeCosterTransaction(t *testing.T) {
+	cost := &RegulatoryFeeCoster{
+		ExchangePerUnit: dec.New(0.001),
+		SellNotionalPct: dec.New(0.0001),
+		SellPerUnit:     dec.New(0.0002),
+		SellPerUnitMax:  dec.New(0.1),
+	}
+
+	tests := []struct {
+		name string
+		give broker.Order
+		want decimal.Decimal
+	}{
+		{
+			name: "buy pays exchange fee only",
+			give: broker.Order{Side: broker.Buy, FilledPrice: dec.New(100), FilledSize: dec.New(100)},
+			want: dec.New(0.1),
+		},
+		{
+			name: "sell pays regulatory fees",
+			give: broker.Order{Side: broker.Sell, FilledPrice: dec.New(100), FilledSize: dec.New(100)},
+			want: dec.New(1.12),
+		},
+		{
+			name: "sell per unit fee is capped",
+			give: broker.Order{Side: broker.Sell, FilledPrice: dec.New(1), FilledSize: dec.New(1000)},
+			want: dec.New(1.2),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := cost.Transaction(tt.give)
+			assert.True(t, act.Equal(tt.want), act.String())
+		})
+	}
+}
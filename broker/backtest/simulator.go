@@ -5,6 +5,8 @@ package backtest
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -12,10 +14,13 @@ import (
 	"github.com/thecolngroup/alphakit/market"
 	"github.com/thecolngroup/gou/dec"
 	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 const _defaultTockInterval = time.Millisecond
 
+const _tradeTockInterval = time.Nanosecond
+
 const _defaultInitialCapital = 1000
 
 // ErrInvalidOrderState is returned when an order is not in a valid state for the simulator to open it.
@@ -24,25 +29,96 @@ var ErrInvalidOrderState = errors.New("order is not valid for processing")
 // ErrRejectedOrder is returned when an order is rejected during processing due to an exceptional condition.
 var ErrRejectedOrder = errors.New("order rejected during processing")
 
+// RejectedOrderError describes an order rejected during processing, and wraps ErrRejectedOrder.
+type RejectedOrderError struct {
+	Order  broker.Order
+	Reason string
+}
+
+// Error returns the error message including the order ID and reason for rejection.
+func (e *RejectedOrderError) Error() string {
+	return fmt.Sprintf("%s: order %s: %s", ErrRejectedOrder, e.Order.ID, e.Reason)
+}
+
+// Unwrap returns ErrRejectedOrder.
+func (e *RejectedOrderError) Unwrap() error {
+	return ErrRejectedOrder
+}
+
 // Simulator is a backtest simulator that simulates the execution of orders against a market.
-// Only a single position can be opened at a time, and must be closed in full before another can be opened.
-// Partial fills are not supported.
-// Account balance can go negative and trading will continue.
+// A single position per asset can be opened at a time, and must be closed in full before another can be opened.
+// Enable reversal to close a position and open the opposite position with a single order larger than the position.
+// Set the Hedge position mode to hold a long and a short position per asset, each selected by the order PositionSide.
+// Positions in different assets are tracked independently and each is marked to the latest price for its asset.
+// To simulate a basket of assets call NextAsset() with the price for each asset,
+// orders for an asset without a price feed of its own are matched against the price given to Next().
+// By default orders are filled in full when matched.
+// Set a Liquidity model to cap the size filled per kline, the remainder of a partially filled order
+// stays open and is filled in later epochs.
+// Without a Margin model account balance can go negative and trading will continue.
+// Set a Margin model to reject orders exceeding the available margin,
+// and force liquidate positions when equity falls below the maintenance margin.
+// Resting orders that add to a position reserve their initial margin until filled or cancelled.
 // Inspect the equity curve to understand equity change over time and the capital requirements for the algo.
 // To advance the simulation call Next() with the next market price.
-// Market and Limit orders are supported. Market orders execute immediately with the last available close price.
-// To place a stop loss or take profit style order use a limit order with 'ReduceOnly' set to true.
+// Market, Limit, Stop and StopLimit orders are supported.
+// Market orders execute immediately with the last available close price.
+// Stop orders trigger when the kline touches the stop price and fill at the worse of the stop price and open price,
+// simulating a price gap through the stop.
+// TrailingStop orders move their stop price on each kline to trail the best price since the order opened,
+// using the high for sell orders and the low for buy orders, before checking for a trigger.
+// Set a LowerTimeframe feed to match orders against each finer grained kline within a received kline,
+// positions are still marked to the received kline and fills are timestamped within the same epoch.
+// Use Snapshot() and Restore() to checkpoint or fork a simulation.
+// Subscribe to receive events as orders and positions change state, and as equity is marked.
+// Set a Latency model to delay orders becoming live, market orders then fill at the open of the next price.
+// Set an OrderBook to fill market orders by walking the depth of the book.
+// To simulate trade by trade call NextTrade() with each trade print in place of klines.
+// Open orders are processed in the sequence they were placed, unless an IntrabarPath model is set,
+// in which case orders are processed in the sequence their prices are traded along the modelled path.
+// Orders honour their TimeInForce: IOC and FOK orders are cancelled if not filled in the epoch they are placed,
+// GTD orders are cancelled when the clock reaches their expiry, and PostOnly limit orders are rejected if marketable.
+// Set a base currency and market.Converter to trade assets quoted in several currencies from one account,
+// profit and fees are settled in the quote currency of each asset and the balance is reported in the base currency.
+// To place a stop loss use a Stop order, and for take profit a Limit order, with 'ReduceOnly' set to true.
 type Simulator struct {
 	clock       Clocker
 	balance     broker.AccountBalance
+	cash        map[string]decimal.Decimal
+	currency    string
+	converter   *market.Converter
 	marketPrice market.Kline
+	assetPrices map[market.Asset]market.Kline
+
+	cost      Coster
+	liquidity Liquidity
+	margin    *Margin
+	path      IntrabarPath
+	mode      PositionMode
+	reversal  bool
 
-	cost Coster
+	lowerTimeframes map[market.Asset]*LowerTimeframe
+	books           map[market.Asset]*market.OrderBook
+
+	latency    Latency
+	liveAt     map[broker.DealID]time.Time
+	activating bool
+
+	subscribers []Subscriber
+
+	epoch          time.Time
+	tradePrint     *market.Trade
+	printAvailable decimal.Decimal
 
 	orders     []broker.Order
 	positions  []broker.Position
 	roundturns []broker.RoundTurn
+	fills      []broker.Fill
 	equity     broker.EquitySeries
+
+	// Indices into positions so that open and per-asset lookups do not scan the full position history
+	openPositions  []int
+	assetPositions map[market.Asset][]int
 }
 
 // NewSimulator create a new backtest simulator with zero cost model.
@@ -53,10 +129,13 @@ func NewSimulator() *Simulator {
 // NewSimulatorWithCost creates a new backtest simulator with the given cost model.
 func NewSimulatorWithCost(cost Coster) *Simulator {
 	return &Simulator{
-		balance: broker.AccountBalance{},
-		clock:   NewClock(),
-		cost:    cost,
-		equity:  make(broker.EquitySeries),
+		balance:     broker.AccountBalance{},
+		clock:       NewClock(),
+		cost:        cost,
+		assetPrices: make(map[market.Asset]market.Kline),
+		equity:      make(broker.EquitySeries),
+
+		assetPositions: make(map[market.Asset][]int),
 	}
 }
 
@@ -65,76 +144,603 @@ func (s *Simulator) SetInitialCapital(amount decimal.Decimal) {
 	s.balance.Trade = amount
 }
 
+// SetCurrency sets the base currency of the account and the converter used to convert amounts between currencies.
+// Profit and fees of an asset with a currency pair in the converter are settled in the quote currency of the pair,
+// other assets are settled in the base currency. Initial capital is held in the base currency.
+// Trade balance, equity and margin are reported in the base currency,
+// and orders for an asset whose quote currency has no conversion rate to the base currency are rejected.
+// The converter rates are updated from the close of each received price,
+// the default price feed given to Next() updates the pair of each asset that has no feed of its own.
+func (s *Simulator) SetCurrency(base string, converter *market.Converter) {
+	s.currency = base
+	s.converter = converter
+	if s.cash == nil {
+		s.cash = make(map[string]decimal.Decimal)
+	}
+}
+
+// SetPositionMode sets how the positions of an asset are tracked, defaults to OneWay.
+func (s *Simulator) SetPositionMode(mode PositionMode) {
+	s.mode = mode
+}
+
+// SetReversal enables an order on the opposite side and larger than an open position to reverse the position.
+// The order closes the position, creating its round-turn, and opens a new opposite position with the excess size.
+// The order fee is split between the two pro rata to size. Reduce-only orders and orders in Hedge mode never reverse.
+// Disabled by default, such an order is rejected.
+func (s *Simulator) SetReversal(enabled bool) {
+	s.reversal = enabled
+}
+
+// SetLiquidity sets the liquidity model used to limit the size of each fill.
+// A nil model fills orders in full.
+func (s *Simulator) SetLiquidity(liquidity Liquidity) {
+	s.liquidity = liquidity
+}
+
+// SetMargin sets the margin model used to limit leverage and liquidate positions.
+// A nil model permits unlimited leverage.
+func (s *Simulator) SetMargin(margin *Margin) {
+	s.margin = margin
+}
+
+// SetIntrabarPath sets the model used to sequence the processing of open orders within a kline.
+// A nil model processes orders in the sequence they were placed.
+func (s *Simulator) SetIntrabarPath(path IntrabarPath) {
+	s.path = path
+}
+
+// SetLowerTimeframe sets a finer grained price feed used to match orders for the given asset,
+// an empty asset sets the feed for the default price feed given to Next().
+// A nil feed matches orders against the received klines.
+func (s *Simulator) SetLowerTimeframe(asset market.Asset, feed *LowerTimeframe) {
+	if s.lowerTimeframes == nil {
+		s.lowerTimeframes = make(map[market.Asset]*LowerTimeframe)
+	}
+	if feed == nil {
+		delete(s.lowerTimeframes, asset)
+		return
+	}
+	s.lowerTimeframes[asset] = feed
+}
+
+// SetOrderBook sets the latest order book for the given asset used to fill market orders.
+// Market orders fill at the volume weighted price of walking the book and are capped at the depth of the book,
+// the unfilled remainder stays open to be filled against a later book.
+// Depth taken by fills is removed from the simulator copy of the book until the next book is set.
+// A nil book fills market orders at the close price.
+func (s *Simulator) SetOrderBook(asset market.Asset, book *market.OrderBook) {
+	if s.books == nil {
+		s.books = make(map[market.Asset]*market.OrderBook)
+	}
+	if book == nil {
+		delete(s.books, asset)
+		return
+	}
+	s.books[asset] = book.Clone()
+}
+
+// Subscribe registers a subscriber to receive the events emitted by the simulator.
+func (s *Simulator) Subscribe(sub Subscriber) {
+	s.subscribers = append(s.subscribers, sub)
+}
+
+// SetLatency sets the model of the delay between an order being placed and becoming live.
+// A nil model opens orders immediately.
+func (s *Simulator) SetLatency(latency Latency) {
+	s.latency = latency
+}
+
 // AddOrder adds an order to the simulator and returns the processed order or an error.
+// When a Latency model is set the order is returned pending with an ID assigned,
+// and becomes live with the first price received after the delay.
 func (s *Simulator) AddOrder(order broker.Order) (broker.Order, error) {
 	var empty broker.Order
-	if order.Side == 0 || order.Type == 0 || order.State() != broker.OrderPending || !order.Size.IsPositive() {
-		return empty, ErrInvalidOrderState
+	if err := validateOrder(order); err != nil {
+		return empty, err
 	}
+
+	if s.latency != nil {
+		if s.liveAt == nil {
+			s.liveAt = make(map[broker.DealID]time.Time)
+		}
+		placedAt := s.clock.Now()
+		order.ID = broker.NewIDWithTime(placedAt)
+		s.liveAt[order.ID] = placedAt.Add(s.latency.Delay())
+		s.orders = append(s.orders, order)
+		return order, nil
+	}
+
 	order, err := s.processOrder(order)
 	if err != nil {
 		return empty, err
 	}
 
+	// Immediate orders are cancelled if not filled in the epoch they are placed
+	if isImmediate(order) && order.State() == broker.OrderOpen {
+		order = s.closeOrder(order)
+	}
+
 	s.orders = append(s.orders, order)
 
 	return order, nil
 }
 
+// AddBracketOrder adds an entry order and its optional stop and take profit legs to the simulator.
+// Legs are held until the entry is filled and are then managed as one-cancels-other:
+// when either leg is filled the other is cancelled, along with the unfilled remainder of the entry.
+// Legs are cancelled if the entry is cancelled.
+// Legs are always reduce-only, and default to the asset, size and position side of the entry.
+// A leg fills no more than the filled size of the entry, so that a partially filled entry is exited in full.
+// The bracket is added in full or not at all: legs that would be rejected are rejected before the entry is added,
+// and the entry is cancelled if a leg fails to be added.
+func (s *Simulator) AddBracketOrder(bracket broker.BracketOrder) (broker.BracketOrder, error) {
+	var empty broker.BracketOrder
+
+	legs := []*broker.Order{&bracket.Stop, &bracket.TakeProfit}
+	for _, leg := range legs {
+		if leg.Type == 0 {
+			continue
+		}
+		leg.Asset = bracket.Enter.Asset
+		leg.ReduceOnly = true
+		if leg.Size.IsZero() {
+			leg.Size = bracket.Enter.Size
+		}
+		if leg.PositionSide == 0 {
+			leg.PositionSide = bracket.Enter.PositionSide
+		}
+		if leg.Side != bracket.Enter.Side.Opposite() {
+			return empty, ErrInvalidOrderState
+		}
+		if err := validateOrder(*leg); err != nil {
+			return empty, err
+		}
+		if reason := s.rejectReason(*leg); reason != "" {
+			return empty, s.rejectOrder(*leg, reason)
+		}
+	}
+
+	enter, err := s.AddOrder(bracket.Enter)
+	if err != nil {
+		return empty, err
+	}
+	bracket.Enter = enter
+
+	added := []broker.DealID{enter.ID}
+	for _, leg := range legs {
+		if leg.Type == 0 {
+			continue
+		}
+		leg.ParentID = enter.ID
+		if *leg, err = s.AddOrder(*leg); err != nil {
+			for _, id := range added {
+				if i, ok := s.findOrder(id); ok && isWorking(s.orders[i]) {
+					s.orders[i] = s.closeOrder(s.orders[i])
+				}
+			}
+			return empty, err
+		}
+		added = append(added, leg.ID)
+	}
+
+	return bracket, nil
+}
+
 // Next advances the simulation by one kline.
+// The kline is the default price feed used for any asset that has not been given its own feed by NextAsset.
 func (s *Simulator) Next(price market.Kline) error {
+	return s.next(market.Asset{}, price)
+}
+
+// NextAsset advances the simulation by one kline for the given asset.
+// Klines for several assets sharing the same start time are processed in the same epoch.
+func (s *Simulator) NextAsset(asset market.Asset, price market.Kline) error {
+	return s.next(asset, price)
+}
 
-	// Init simulation clock the first time a price is received
+// NextTrade advances the simulation by one trade print for the given asset,
+// an empty asset advances the default price feed.
+// Limit orders are filled at their limit price by prints at or through the limit from an aggressor on the opposite side,
+// and the total size filled by each print is capped at the size of the print.
+// Stop orders are triggered and filled by any print at or through the stop price.
+func (s *Simulator) NextTrade(asset market.Asset, trade market.Trade) error {
+	s.tradePrint = &trade
+	s.printAvailable = trade.Size
+	defer func() { s.tradePrint = nil }()
+	return s.next(asset, trade.Kline())
+}
+
+func (s *Simulator) next(asset market.Asset, price market.Kline) error {
+
+	// Init simulation clock the first time a price is received,
+	// trade prints use a finer tock so that orders do not overrun the next print
 	if s.clock.Peek().IsZero() {
-		s.clock.Start(price.Start, _defaultTockInterval)
+		tock := _defaultTockInterval
+		if s.tradePrint != nil {
+			tock = _tradeTockInterval
+		}
+		s.clock.Start(price.Start, tock)
+		s.epoch = price.Start
 	}
 
-	// Advance the clock epoch to the start time of the kline
-	s.clock.Advance(price.Start)
+	// Advance the clock epoch to the start time of the kline,
+	// klines for other assets in the same epoch do not move the clock
+	if price.Start.After(s.clock.Peek()) {
+		s.clock.Advance(price.Start)
+		s.epoch = price.Start
+	}
 
-	// Set the market price used in this epoch to the received price
-	s.marketPrice = price
+	// Update the conversion rate of the asset currency pair before it is used to settle fills,
+	// the default price feed also updates the pair of each asset without a feed of its own
+	if s.converter != nil {
+		s.converter.ReceivePrice(asset, price)
+		if asset == (market.Asset{}) {
+			for _, paired := range s.converter.Assets() {
+				if paired != asset && s.isQuotedBy(paired, asset) {
+					s.converter.ReceivePrice(paired, price)
+				}
+			}
+		}
+	}
 
-	for i := range s.orders {
-		order := s.orders[i]
-		if order.State() != broker.OrderOpen {
-			continue
+	// Match orders against each kline of the lower timeframe feed if available,
+	// otherwise against the received price
+	quotes := []market.Kline{price}
+	if feed, ok := s.lowerTimeframes[asset]; ok {
+		if window := feed.Window(price.Start); len(window) > 0 {
+			quotes = window
 		}
-		order, err := s.processOrder(order)
-		if err != nil {
+	}
+	for _, quote := range quotes {
+		s.setQuote(asset, quote)
+		if err := s.activateOrders(asset, quote); err != nil {
 			return err
 		}
-		s.orders[i] = order
+		for _, i := range s.sequenceOrders(asset) {
+			// Order state is checked on each iteration as processing an order may close its siblings
+			order := s.orders[i]
+			if order.State() != broker.OrderOpen {
+				continue
+			}
+			order, err := s.processOrder(order)
+			if err != nil {
+				return err
+			}
+			s.orders[i] = order
+		}
 	}
 
-	// Init equity balance with trade (realized cash) balance
-	equity := s.balance.Trade
+	// Set the market price used for the remainder of this epoch to the received price
+	s.setQuote(asset, price)
 
-	// Mark open position to market and add unrealized PNL to equity
-	if position := s.getPosition(); position.State() == broker.OrderOpen {
+	// Mark each open position to the market price of its asset
+	for _, i := range s.openPositions {
+		position := s.positions[i]
+		quote := s.quote(position.Asset)
 		// Deduct funding fees from position PNL
-		position.Cost = position.Cost.Add(s.cost.Funding(position, s.marketPrice.C, s.clock.Elapsed()))
+		funding := s.funding(s.cost, position, quote.C)
+		position.Cost = position.Cost.Add(funding)
 		// Mark position PNL to latest price
-		position = markPositionToMarket(position, s.marketPrice.C)
-		s.upsertPosition(position)
+		position = markPositionToMarket(position, quote.C)
+		s.positions[i] = position
+		if !funding.IsZero() {
+			s.emit(Event{Type: FundingCharged, Position: &position, Amount: funding})
+		}
+	}
 
-		equity = equity.Add(position.PNL)
+	// Force liquidate positions with equity below the maintenance margin
+	if s.margin != nil {
+		if err := s.liquidatePositions(); err != nil {
+			return err
+		}
 	}
 
 	// Update equity balance
+	equity := s.markedEquity()
 	s.equity[broker.Timestamp(s.clock.Peek().UnixMilli())] = equity
 	s.balance.Equity = equity
+	s.emit(Event{Type: EquityMarked, Amount: equity})
+
+	return nil
+}
+
+// emit sends an event to all subscribers.
+func (s *Simulator) emit(event Event) {
+	if len(s.subscribers) == 0 {
+		return
+	}
+	event.Time = s.clock.Peek()
+	for _, sub := range s.subscribers {
+		sub.ReceiveEvent(event)
+	}
+}
+
+// rejectOrder emits an OrderRejected event and returns a RejectedOrderError.
+func (s *Simulator) rejectOrder(order broker.Order, reason string) error {
+	s.emit(Event{Type: OrderRejected, Order: &order, Reason: reason})
+	return &RejectedOrderError{Order: order, Reason: reason}
+}
+
+// activateOrders opens pending orders that have become live by the start of the given quote.
+// Orders are opened at the time they became live, and market orders fill at the open of the quote.
+// Orders rejected when becoming live are closed unfilled.
+func (s *Simulator) activateOrders(feedAsset market.Asset, quote market.Kline) error {
+	s.activating = true
+	defer func() { s.activating = false }()
+
+	for i := range s.orders {
+		order := s.orders[i]
+		liveAt, ok := s.liveAt[order.ID]
+		if !ok || order.State() != broker.OrderPending || !s.isQuotedBy(order.Asset, feedAsset) {
+			continue
+		}
+		if liveAt.After(quote.Start) {
+			continue
+		}
+		order, err := s.processOrder(order)
+		delete(s.liveAt, order.ID)
+		switch {
+		case errors.Is(err, ErrRejectedOrder):
+			order.ClosedAt = s.clock.Now()
+		case err != nil:
+			return err
+		}
+
+		// Immediate orders are cancelled if not filled when they become live
+		if isImmediate(order) && order.State() == broker.OrderOpen {
+			order = s.closeOrder(order)
+		}
+		s.orders[i] = order
+	}
+	return nil
+}
+
+// funding returns the funding cost of the position charged by the cost model,
+// models implementing ScheduledFunder are charged at the simulation time and the models of a CompositeCoster are charged separately.
+func (s *Simulator) funding(cost Coster, position broker.Position, price decimal.Decimal) decimal.Decimal {
+	switch c := cost.(type) {
+	case *CompositeCoster:
+		var total decimal.Decimal
+		for _, cost := range c.Costers {
+			total = total.Add(s.funding(cost, position, price))
+		}
+		return total
+	case ScheduledFunder:
+		return c.FundingAt(position, price, s.clock.Peek())
+	}
+	return cost.Funding(position, price, s.clock.Elapsed())
+}
+
+// fundingOpener is implemented by cost models that track the funding interval of each position,
+// such as PerpCoster and the models embedding it.
+type fundingOpener interface {
+	openFunding(position broker.Position, elapsed time.Duration)
+}
+
+// openFunding starts the funding interval of a new position in the cost model,
+// so that a position is funded from the epoch it was opened.
+func (s *Simulator) openFunding(cost Coster, position broker.Position) {
+	switch c := cost.(type) {
+	case *CompositeCoster:
+		for _, cost := range c.Costers {
+			s.openFunding(cost, position)
+		}
+	case fundingOpener:
+		c.openFunding(position, s.clock.Elapsed())
+	}
+}
+
+// markedEquity returns the trade (realized cash) balance plus the unrealized PNL of open positions,
+// converted to the base currency.
+func (s *Simulator) markedEquity() decimal.Decimal {
+	equity := s.tradeBalance()
+	for _, i := range s.openPositions {
+		position := s.positions[i]
+		equity = equity.Add(s.convert(position.PNL, s.quoteCurrency(position.Asset), s.currency))
+	}
+	return equity
+}
+
+// tradeBalance returns the trade (realized cash) balance of all currencies converted to the base currency.
+func (s *Simulator) tradeBalance() decimal.Decimal {
+	trade := s.balance.Trade
+	for currency, amount := range s.cash {
+		trade = trade.Add(s.convert(amount, currency, s.currency))
+	}
+	return trade
+}
+
+// currencyBalances returns the unconverted balance of each currency held in cash or by open positions, sorted by currency.
+func (s *Simulator) currencyBalances() []broker.CurrencyBalance {
+	balances := map[string]broker.CurrencyBalance{
+		s.currency: {Currency: s.currency, Trade: s.balance.Trade, Equity: s.balance.Trade},
+	}
+	for currency, amount := range s.cash {
+		balances[currency] = broker.CurrencyBalance{Currency: currency, Trade: amount, Equity: amount}
+	}
+	for _, i := range s.openPositions {
+		position := s.positions[i]
+		currency := s.quoteCurrency(position.Asset)
+		balance := balances[currency]
+		balance.Currency = currency
+		balance.Equity = balance.Equity.Add(position.PNL)
+		balances[currency] = balance
+	}
+
+	out := make([]broker.CurrencyBalance, 0, len(balances))
+	for _, balance := range balances {
+		out = append(out, balance)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Currency < out[j].Currency })
+	return out
+}
+
+// quoteCurrency returns the currency the profit and fees of the asset are settled in.
+func (s *Simulator) quoteCurrency(asset market.Asset) string {
+	if s.converter != nil {
+		if pair, ok := s.converter.Pair(asset); ok {
+			return pair.Quote
+		}
+	}
+	return s.currency
+}
+
+// isConvertible returns true if amounts in the quote currency of the asset can be converted to the base currency.
+func (s *Simulator) isConvertible(asset market.Asset) bool {
+	if s.converter == nil {
+		return true
+	}
+	_, err := s.converter.Convert(decimal.Zero, s.quoteCurrency(asset), s.currency)
+	return err == nil
+}
+
+// convert converts an amount between currencies, or returns zero if there is no conversion rate.
+// Orders are rejected for assets that are not convertible, so the amounts held are always convertible.
+func (s *Simulator) convert(amount decimal.Decimal, from, to string) decimal.Decimal {
+	if from == to {
+		return amount
+	}
+	converted, err := s.converter.Convert(amount, from, to)
+	if err != nil {
+		return decimal.Zero
+	}
+	return converted
+}
+
+// credit adds an amount in the given currency to the trade balance.
+func (s *Simulator) credit(currency string, amount decimal.Decimal) {
+	if currency == s.currency {
+		s.balance.Trade = s.balance.Trade.Add(amount)
+		return
+	}
+	s.cash[currency] = s.cash[currency].Add(amount)
+}
+
+// marginUsed returns the initial margin of open positions and resting orders, or the maintenance margin of open positions.
+func (s *Simulator) marginUsed(maintenance bool) decimal.Decimal {
+	used := decimal.Zero
+	if s.margin == nil {
+		return used
+	}
+	for _, i := range s.openPositions {
+		position := s.positions[i]
+		margin := positionMargin(position, s.margin.Rule(position.Asset), maintenance)
+		used = used.Add(s.convert(margin, s.quoteCurrency(position.Asset), s.currency))
+	}
+	if maintenance {
+		return used
+	}
+	// Resting orders reserve the initial margin of the exposure they would add
+	for i := range s.orders {
+		if s.orders[i].State() == broker.OrderOpen {
+			used = used.Add(s.orderMargin(s.orders[i]))
+		}
+	}
+	return used
+}
+
+// liquidatePositions closes any position whose liquidation price is reached within the kline of its asset.
+// A position filled in the current epoch is only tested against the prices traded after its fill, see liquidationQuote.
+// The position is closed at the liquidation price, or the open price if the market gaps through it,
+// and a liquidation fee is charged. Open orders for the liquidated asset are cancelled.
+func (s *Simulator) liquidatePositions() error {
+	// Iterate a copy of the open index as liquidated positions are removed from it
+	for _, i := range slices.Clone(s.openPositions) {
+		position := s.positions[i]
+		rule := s.margin.Rule(position.Asset)
+		currency := s.quoteCurrency(position.Asset)
+		excess := s.markedEquity().Sub(s.convert(position.PNL, currency, s.currency)).Sub(s.marginUsed(true))
+		excess = s.convert(excess, s.currency, currency).Add(positionMargin(position, rule, true))
+		liqPrice := liquidationPrice(position, rule.MaintenanceMarginPct, excess)
+
+		order := broker.Order{
+			Asset:      position.Asset,
+			Side:       position.Side.Opposite(),
+			Type:       broker.Stop,
+			StopPrice:  liqPrice,
+			Size:       position.Size,
+			ReduceOnly: true,
+		}
+		if s.mode == Hedge {
+			order.PositionSide = position.Side
+		}
+		quote := s.liquidationQuote(position)
+		if !liqPrice.IsPositive() || !triggerOrder(order, quote) {
+			continue
+		}
+
+		order = s.openOrder(order)
+		order.TriggeredAt = order.OpenedAt
+		order.FilledAt = s.clock.Now()
+		order.FilledPrice = matchOrder(order, quote)
+		order.FilledSize = order.Size
+		order.Liquidity = broker.Taker
+		order.Fee = order.FilledPrice.Mul(order.FilledSize).Mul(rule.LiquidationFeePct)
 
+		position, err := s.processPosition(position, order)
+		if err != nil {
+			return err
+		}
+		s.upsertPosition(position)
+		s.emit(Event{Type: OrderFilled, Order: &order})
+		s.emit(Event{Type: PositionLiquidated, Position: &position, Amount: order.Fee, Reason: "equity below maintenance margin"})
+		s.orders = append(s.orders, s.closeOrder(order))
+
+		for j := range s.orders {
+			if s.orders[j].Asset.Equal(position.Asset) && s.orders[j].State() == broker.OrderOpen &&
+				(s.mode != Hedge || s.orders[j].PositionSide == position.Side) {
+				s.orders[j] = s.closeOrder(s.orders[j])
+			}
+		}
+	}
 	return nil
 }
 
+// liquidationQuote returns the part of the kline of the position asset traded after the latest fill of the position.
+// The fill is placed on the intrabar path, or the pessimistic path if none is set, at the first point its price is traded.
+// The returned kline opens at the fill price, and is the full kline if the position was not filled in the current epoch.
+func (s *Simulator) liquidationQuote(position broker.Position) market.Kline {
+	quote := s.quote(position.Asset)
+
+	var fill broker.Fill
+	for i := len(s.fills) - 1; i >= 0 && s.isSameEpoch(s.fills[i].FilledAt); i-- {
+		if s.fills[i].PositionID == position.ID {
+			fill = s.fills[i]
+			break
+		}
+	}
+	if fill.PositionID == "" {
+		return quote
+	}
+
+	pathFn := s.path
+	if pathFn == nil {
+		pathFn = PathPessimistic
+	}
+	path := pathFn(quote, position.Side)
+	// Costs can place the fill price outside the kline range
+	price := decimal.Min(decimal.Max(fill.Price, quote.L), quote.H)
+	distance, ok := pathDistance(path, price)
+	if !ok {
+		return quote
+	}
+
+	after := market.Kline{Start: quote.Start, O: price, H: price, L: price, C: quote.C}
+	for _, traded := range path[distance.IntPart()+1:] {
+		after.H = decimal.Max(after.H, traded)
+		after.L = decimal.Min(after.L, traded)
+	}
+	return after
+}
+
 // CancelOrders cancels all open orders and returns the cancelled orders.
 func (s *Simulator) CancelOrders() []broker.Order {
 	cancelled := make([]broker.Order, 0, len(s.orders))
 	for i := range s.orders {
 		order := s.orders[i]
-		if order.State() == broker.OrderOpen {
-			order.ClosedAt = s.clock.Now()
+		if isWorking(order) {
+			order = s.closeOrder(order)
 			cancelled = append(cancelled, order)
 			s.orders[i] = order
 		}
@@ -142,11 +748,73 @@ func (s *Simulator) CancelOrders() []broker.Order {
 	return cancelled
 }
 
+// CancelOrder cancels the open order with the given ID and returns the cancelled order.
+// Open bracket legs of a cancelled entry order are also cancelled.
+func (s *Simulator) CancelOrder(id broker.DealID) (broker.Order, error) {
+	var empty broker.Order
+	i, ok := s.findOrder(id)
+	if !ok {
+		return empty, broker.ErrNotFound
+	}
+	if !isWorking(s.orders[i]) {
+		return empty, ErrInvalidOrderState
+	}
+	s.orders[i] = s.closeOrder(s.orders[i])
+	if !s.orders[i].FilledSize.IsPositive() {
+		for j := range s.orders {
+			if s.orders[j].ParentID == id && isWorking(s.orders[j]) {
+				s.orders[j] = s.closeOrder(s.orders[j])
+			}
+		}
+	}
+	return s.orders[i], nil
+}
+
+// AmendOrder amends the price and/or size of the open order with the given ID and returns the amended order.
+// Zero value fields of opts are left unchanged. The size cannot be amended below the size already filled.
+func (s *Simulator) AmendOrder(id broker.DealID, opts broker.AmendOpts) (broker.Order, error) {
+	var empty broker.Order
+	i, ok := s.findOrder(id)
+	if !ok {
+		return empty, broker.ErrNotFound
+	}
+	order := s.orders[i]
+	if !isWorking(order) {
+		return empty, ErrInvalidOrderState
+	}
+	if opts.LimitPrice.IsNegative() || opts.StopPrice.IsNegative() || opts.Size.IsNegative() {
+		return empty, ErrInvalidOrderState
+	}
+	if opts.LimitPrice.IsPositive() {
+		order.LimitPrice = opts.LimitPrice
+	}
+	if opts.StopPrice.IsPositive() {
+		order.StopPrice = opts.StopPrice
+	}
+	if opts.Size.IsPositive() {
+		if opts.Size.LessThanOrEqual(order.FilledSize) {
+			return empty, ErrInvalidOrderState
+		}
+		order.Size = opts.Size
+	}
+	s.orders[i] = order
+	return order, nil
+}
+
+// Order returns the order with the given ID.
+func (s *Simulator) Order(id broker.DealID) (broker.Order, error) {
+	i, ok := s.findOrder(id)
+	if !ok {
+		return broker.Order{}, broker.ErrNotFound
+	}
+	return s.orders[i], nil
+}
+
 // Orders returns a copy of all historical and open orders.
 func (s *Simulator) Orders() []broker.Order {
 	copied := make([]broker.Order, len(s.orders))
 	copy(copied, s.orders)
-	return s.orders
+	return copied
 }
 
 // Positions returns a copy of all historical and open positions.
@@ -156,6 +824,74 @@ func (s *Simulator) Positions() []broker.Position {
 	return copied
 }
 
+// ListOrders returns the historical and open orders that match the filter.
+func (s *Simulator) ListOrders(filter *broker.OrderFilter) []broker.Order {
+	var out []broker.Order
+	for i := range s.orders {
+		if filter.Match(s.orders[i]) {
+			out = append(out, s.orders[i])
+		}
+	}
+	return out
+}
+
+// ListPositions returns the historical and open positions that match the filter, in the order they were opened.
+// Open positions and positions of a single asset are looked up by index rather than scanning all positions.
+func (s *Simulator) ListPositions(filter *broker.PositionFilter) []broker.Position {
+	var indices []int
+	switch {
+	case filter != nil && len(filter.States) == 1 && filter.States[0] == broker.PositionOpen:
+		indices = s.openPositions
+	case filter != nil && filter.Asset != (market.Asset{}):
+		indices = s.assetPositions[filter.Asset]
+	default:
+		var out []broker.Position
+		for i := range s.positions {
+			if filter.Match(s.positions[i]) {
+				out = append(out, s.positions[i])
+			}
+		}
+		return out
+	}
+
+	var out []broker.Position
+	for _, i := range indices {
+		if filter.Match(s.positions[i]) {
+			out = append(out, s.positions[i])
+		}
+	}
+	return out
+}
+
+// ListFills returns the historical fills that match the filter.
+func (s *Simulator) ListFills(filter *broker.FillFilter) []broker.Fill {
+	var out []broker.Fill
+	for i := range s.fills {
+		if filter.Match(s.fills[i]) {
+			out = append(out, s.fills[i])
+		}
+	}
+	return out
+}
+
+// ListRoundTurns returns the historical round-turns that match the filter.
+func (s *Simulator) ListRoundTurns(filter *broker.RoundTurnFilter) []broker.RoundTurn {
+	var out []broker.RoundTurn
+	for i := range s.roundturns {
+		if filter.Match(s.roundturns[i]) {
+			out = append(out, s.roundturns[i])
+		}
+	}
+	return out
+}
+
+// Fills returns a copy of all historical fills.
+func (s *Simulator) Fills() []broker.Fill {
+	copied := make([]broker.Fill, len(s.fills))
+	copy(copied, s.fills)
+	return copied
+}
+
 // RoundTurns returns a copy of all historical roundturns.
 func (s *Simulator) RoundTurns() []broker.RoundTurn {
 	copied := make([]broker.RoundTurn, len(s.roundturns))
@@ -172,7 +908,31 @@ func (s *Simulator) EquityHistory() broker.EquitySeries {
 
 // Balance returns the current account balance.
 func (s *Simulator) Balance() broker.AccountBalance {
-	return s.balance
+	balance := s.balance
+	if s.converter != nil {
+		balance.Currency = s.currency
+		balance.Trade = s.tradeBalance()
+		balance.Currencies = s.currencyBalances()
+	}
+	balance.MarginUsed = s.marginUsed(false)
+	balance.AvailableMargin = balance.Equity.Sub(balance.MarginUsed)
+	return balance
+}
+
+// rejectReason returns the reason the order would be rejected on opening, or empty if it would be accepted.
+func (s *Simulator) rejectReason(order broker.Order) string {
+	switch {
+	// Post-only orders must not fill immediately as a taker
+	case order.TimeInForce == broker.PostOnly && isMarketable(order, s.quote(order.Asset).C):
+		return "post-only order is marketable"
+	// Orders must settle in a currency that can be converted to the base currency
+	case !s.isConvertible(order.Asset):
+		return "no conversion rate for quote currency"
+	// Orders that increase exposure must not exceed the available margin
+	case s.margin != nil && !s.hasMarginFor(order):
+		return "insufficient margin"
+	}
+	return ""
 }
 
 func (s *Simulator) processOrder(order broker.Order) (broker.Order, error) {
@@ -180,96 +940,439 @@ func (s *Simulator) processOrder(order broker.Order) (broker.Order, error) {
 
 	switch order.State() {
 	case broker.OrderPending:
-		if order, err = s.processOrder(s.openOrder(order)); err != nil {
+
+		// Orders without a position side in Hedge mode apply to the position they would open or reduce
+		if s.mode == Hedge && order.PositionSide == 0 {
+			order.PositionSide = order.Side
+			if order.ReduceOnly {
+				order.PositionSide = order.Side.Opposite()
+			}
+		}
+
+		// State transition condition:
+		// Orders must be accepted by the market and margin checks
+		if reason := s.rejectReason(order); reason != "" {
+			return order, s.rejectOrder(order, reason)
+		}
+
+		order = s.openOrder(order)
+		// Trailing stops without an initial stop price trail the close price at the time of opening
+		if order.Type == broker.TrailingStop {
+			order = trailOrder(order, s.quote(order.Asset).C)
+		}
+		if order, err = s.processOrder(order); err != nil {
 			return order, err
 		}
 	case broker.OrderOpen:
 
+		// State transition condition:
+		// Good till date orders are cancelled when the clock reaches the expiry time
+		if order.TimeInForce == broker.GTD && !s.clock.Peek().Before(order.ExpiresAt) {
+			order = s.closeOrder(order)
+			break
+		}
+
 		// State transition condition:
 		// Guard for temporal logic error whereby a past or future price is used to fill an order
-		// Limit orders cannot be filled in the same epoch as the current price
-		if order.Type == broker.Limit && equalClock(order.OpenedAt, s.clock.Peek()) {
+		// Limit and stop orders cannot be filled in the same epoch as the current price,
+		// except immediate limit orders that are marketable at the current price
+		sameEpoch := s.isSameEpoch(order.OpenedAt)
+		if order.Type != broker.Market && sameEpoch && !(isImmediate(order) && order.Type == broker.Limit) {
 			break
 		}
 
+		// State transition condition:
+		// Bracket legs are held until the entry order is filled,
+		// and are cancelled if the entry is closed without a fill
+		if order.ParentID != "" {
+			if i, ok := s.findOrder(order.ParentID); ok && !s.orders[i].FilledSize.IsPositive() {
+				if s.orders[i].State() == broker.OrderClosed {
+					order = s.closeOrder(order)
+				}
+				break
+			}
+		}
+
+		// Trail the stop price to the best price of the kline before checking for a trigger
+		if order.Type == broker.TrailingStop && order.TriggeredAt.IsZero() {
+			quote := s.quote(order.Asset)
+			best := quote.H
+			if order.Side == broker.Buy {
+				best = quote.L
+			}
+			order = trailOrder(order, best)
+		}
+
+		// State transition condition:
+		// Stop orders must be triggered by the market price touching the stop price
+		var triggered bool
+		if order.Type.IsStop() && order.TriggeredAt.IsZero() {
+			if !triggerOrder(order, s.quote(order.Asset)) {
+				break
+			}
+			order.TriggeredAt = s.clock.Now()
+			triggered = true
+		}
+
 		// State transition condition:
 		// Order price must be matched to the available market price
 		// Market type orders will always match the current close price
-		matchedPrice := matchOrder(order, s.marketPrice)
+		matchedPrice := matchOrder(order, s.quote(order.Asset))
+		if s.tradePrint != nil {
+			matchedPrice = matchTradeOrder(order, *s.tradePrint)
+		}
+		if isImmediate(order) && sameEpoch {
+			matchedPrice = matchImmediateOrder(order, s.quote(order.Asset))
+		}
+		// Market orders becoming live after a latency delay fill at the next available price
+		if order.Type == broker.Market && s.activating {
+			matchedPrice = s.quote(order.Asset).O
+		}
 		if !matchedPrice.IsPositive() {
 			break
 		}
 
-		// Transition to filled state
-		if order, err = s.processOrder(s.fillOrder(order, matchedPrice)); err != nil {
-			return order, err
+		// Bracket legs exit no more than the filled size of the entry, which may be partially filled
+		if order.ParentID != "" {
+			if i, ok := s.findOrder(order.ParentID); ok && order.Size.GreaterThan(s.orders[i].FilledSize) {
+				order.Size = s.orders[i].FilledSize
+			}
 		}
 
-	case broker.OrderFilled:
-		position, err := s.processPosition(s.getPosition(), order)
+		// State transition condition:
+		// Fill or kill orders must be filled in full
+		if order.TimeInForce == broker.FOK && s.fillableSize(order).LessThan(order.Size) {
+			break
+		}
+
+		// Limit orders hit while resting are makers, orders that cross the market on arrival are takers:
+		// immediate orders in the epoch placed, stop limits in the epoch triggered and limits marketable when becoming live
+		order.Liquidity = broker.Taker
+		if order.Type == broker.Limit || order.Type == broker.StopLimit {
+			arriving := (isImmediate(order) && sameEpoch) || triggered ||
+				(s.activating && isMarketable(order, s.quote(order.Asset).O))
+			if !arriving {
+				order.Liquidity = broker.Maker
+			}
+		}
+
+		// State transition condition:
+		// Orders the position would reject are rejected before filling,
+		// so that a rejected order takes no liquidity and is not costed
+		pending := order
+		pending.FilledSize = s.fillableSize(order)
+		if pending.FilledSize.IsPositive() {
+			if reason := s.fillRejectReason(s.getPosition(order.Asset, order.PositionSide), pending); reason != "" {
+				return order, s.rejectOrder(pending, reason)
+			}
+		}
+
+		// Fill as much of the order as liquidity permits and apply the fill to the position
+		var fill broker.Order
+		order, fill = s.fillOrder(order, matchedPrice)
+		if !fill.FilledSize.IsPositive() {
+			break
+		}
+		position, err := s.processPosition(s.getPosition(order.Asset, order.PositionSide), fill)
 		if err != nil {
 			return order, err
 		}
 		s.upsertPosition(position)
+		s.emit(Event{Type: OrderFilled, Order: &fill})
+
+		// One-cancels-other: a fill on a bracket leg cancels its sibling legs
+		if order.ParentID != "" {
+			s.cancelSiblings(order)
+		}
+
+		// Transition to filled state once the full order size is filled,
+		// a partially filled order remains open to be filled in a later epoch
+		if order.State() == broker.OrderFilled {
+			if order, err = s.processOrder(order); err != nil {
+				return order, err
+			}
+		}
+
+	case broker.OrderFilled:
 		order = s.closeOrder(order)
 	}
 	return order, nil
 }
 
 func (s *Simulator) openOrder(order broker.Order) broker.Order {
-	order.ID = broker.NewIDWithTime(s.clock.Now())
-	order.OpenedAt = s.clock.Now()
+	// Orders delayed by latency already have an ID and open at the time they became live
+	if liveAt, ok := s.liveAt[order.ID]; ok && s.activating {
+		order.OpenedAt = liveAt
+	} else {
+		if order.ID == "" {
+			order.ID = broker.NewIDWithTime(s.clock.Now())
+		}
+		order.OpenedAt = s.clock.Now()
+	}
+	s.emit(Event{Type: OrderOpened, Order: &order})
 	return order
 }
 
-func (s *Simulator) fillOrder(order broker.Order, matchedPrice decimal.Decimal) broker.Order {
-	order.FilledAt = s.clock.Now()
+// fillOrder fills as much of the remaining order size as liquidity permits at the matched price.
+// Returns the updated order and the fill applied by this call.
+// FilledPrice of the updated order is the volume-weighted average price of all fills.
+func (s *Simulator) fillOrder(order broker.Order, matchedPrice decimal.Decimal) (broker.Order, broker.Order) {
 	var fillPrice decimal.Decimal
+	fillableSize := s.fillableSize(order)
+
+	// Slippage is priced on the size of this fill, so that each partial fill is not charged for the whole remainder
+	sized := order
+	sized.Size = order.FilledSize.Add(fillableSize)
+	slippage := s.cost.Slippage(sized, matchedPrice, s.quote(order.Asset))
 
 	switch order.Side {
 	case broker.Buy:
-		fillPrice = matchedPrice.Add(s.cost.Slippage(matchedPrice))
+		fillPrice = matchedPrice.Add(slippage)
 		fillPrice = fillPrice.Add(s.cost.Spread(fillPrice))
 	case broker.Sell:
-		fillPrice = matchedPrice.Sub(s.cost.Slippage(matchedPrice))
+		fillPrice = matchedPrice.Sub(slippage)
 		fillPrice = fillPrice.Sub(s.cost.Spread(fillPrice))
 	}
 
-	order.FilledPrice = fillPrice
-	order.FilledSize = order.Size
-	order.Fee = s.cost.Transaction(order)
+	fill := order
+	fill.FilledPrice = fillPrice
+	fill.FilledSize = fillableSize
 
-	return order
+	// Market orders walk the order book when available, the book price already reflects spread and slippage
+	if book, ok := s.books[order.Asset]; ok && order.Type == broker.Market {
+		side := market.TakerBuy
+		if order.Side == broker.Sell {
+			side = market.TakerSell
+		}
+		fill.FilledPrice, fill.FilledSize = book.Take(side, fill.FilledSize)
+	}
+
+	if !fill.FilledSize.IsPositive() {
+		return order, broker.Order{}
+	}
+	fill.FilledAt = s.clock.Now()
+	fill.Fee = s.cost.Transaction(fill)
+	if s.tradePrint != nil {
+		s.printAvailable = s.printAvailable.Sub(fill.FilledSize)
+	}
+
+	filledSize := order.FilledSize.Add(fill.FilledSize)
+	order.FilledPrice = order.FilledPrice.Mul(order.FilledSize).Add(fill.FilledPrice.Mul(fill.FilledSize)).Div(filledSize)
+	order.FilledSize = filledSize
+	order.Fee = order.Fee.Add(fill.Fee)
+	if order.FilledSize.GreaterThanOrEqual(order.Size) {
+		order.FilledAt = fill.FilledAt
+	}
+
+	return order, fill
+}
+
+// hasMarginFor returns true if the available margin covers the initial margin of the order.
+// Orders that reduce an open position do not require margin, and a reversal requires margin for the excess size only.
+func (s *Simulator) hasMarginFor(order broker.Order) bool {
+	available := s.markedEquity().Sub(s.marginUsed(false))
+	return s.orderMargin(order).LessThanOrEqual(available)
+}
+
+// orderMargin returns the initial margin of the unfilled order size that increases exposure, in the base currency.
+// Orders that reduce an open position require no margin, and a reversal requires margin for the excess size only.
+func (s *Simulator) orderMargin(order broker.Order) decimal.Decimal {
+	if order.ReduceOnly {
+		return decimal.Zero
+	}
+	size := order.Size.Sub(order.FilledSize)
+	position := s.getPosition(order.Asset, order.PositionSide)
+	if position.State() == broker.PositionOpen && position.Side != order.Side {
+		if !s.canReverse(order) || size.LessThanOrEqual(position.Size) {
+			return decimal.Zero
+		}
+		size = size.Sub(position.Size)
+	}
+
+	price := s.quote(order.Asset).C
+	switch {
+	case order.Type == broker.Limit || order.Type == broker.StopLimit:
+		price = order.LimitPrice
+	case order.Type.IsStop() && order.StopPrice.IsPositive():
+		price = order.StopPrice
+	}
+
+	required := size.Mul(price).Mul(s.margin.Rule(order.Asset).InitialRate())
+	return s.convert(required, s.quoteCurrency(order.Asset), s.currency)
+}
+
+// findOrder returns the index of the order with the given ID.
+func (s *Simulator) findOrder(id broker.DealID) (int, bool) {
+	for i := len(s.orders) - 1; i >= 0; i-- {
+		if s.orders[i].ID == id {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// cancelSiblings cancels the open bracket legs that share a parent with the given order,
+// and the parent entry if it is partially filled.
+func (s *Simulator) cancelSiblings(order broker.Order) {
+	for i := range s.orders {
+		sibling := s.orders[i]
+		if (sibling.ID == order.ParentID || (sibling.ParentID == order.ParentID && sibling.ID != order.ID)) &&
+			sibling.State() == broker.OrderOpen {
+			s.orders[i] = s.closeOrder(sibling)
+		}
+	}
+}
+
+// fillableSize returns the remaining size of the order that liquidity permits to be filled.
+func (s *Simulator) fillableSize(order broker.Order) decimal.Decimal {
+	size := order.Size.Sub(order.FilledSize)
+	if s.liquidity != nil {
+		size = decimal.Min(size, s.liquidity.Available(order, s.quote(order.Asset)))
+	}
+	if s.tradePrint != nil {
+		size = decimal.Min(size, s.printAvailable)
+	}
+	return size
 }
 
 func (s *Simulator) closeOrder(order broker.Order) broker.Order {
+	delete(s.liveAt, order.ID)
 	order.ClosedAt = s.clock.Now()
+	if order.FilledAt.IsZero() {
+		s.emit(Event{Type: OrderCancelled, Order: &order})
+	}
 	return order
 }
 
-func (s *Simulator) getPosition() broker.Position {
-	var empty, position broker.Position
-	if len(s.positions) == 0 {
-		return empty
+// sequenceOrders returns the indices of open orders quoted by the given feed asset in processing sequence.
+// When an IntrabarPath is set, orders are sequenced by how far along the path their price is first traded,
+// orders not traded on the path are processed last in the sequence they were placed.
+func (s *Simulator) sequenceOrders(feedAsset market.Asset) []int {
+	type sequenced struct {
+		index    int
+		distance decimal.Decimal
+		traded   bool
 	}
-	position = s.positions[len(s.positions)-1]
-	if position.State() == broker.PositionClosed {
-		return empty
+
+	var seq []sequenced
+	for i := range s.orders {
+		order := s.orders[i]
+		if order.State() != broker.OrderOpen || !s.isQuotedBy(order.Asset, feedAsset) {
+			continue
+		}
+		next := sequenced{index: i}
+		if s.path != nil {
+			path := s.path(s.quote(order.Asset), s.getPosition(order.Asset, order.PositionSide).Side)
+			next.distance, next.traded = orderPathDistance(order, path)
+		}
+		seq = append(seq, next)
 	}
-	return position
+
+	if s.path != nil {
+		sort.SliceStable(seq, func(i, j int) bool {
+			if seq[i].traded != seq[j].traded {
+				return seq[i].traded
+			}
+			return seq[i].distance.LessThan(seq[j].distance)
+		})
+	}
+
+	indices := make([]int, len(seq))
+	for i := range seq {
+		indices[i] = seq[i].index
+	}
+	return indices
+}
+
+// setQuote sets the latest price for the asset, an empty asset sets the default price feed.
+func (s *Simulator) setQuote(asset market.Asset, price market.Kline) {
+	if asset == (market.Asset{}) {
+		s.marketPrice = price
+		return
+	}
+	if s.assetPrices == nil {
+		s.assetPrices = make(map[market.Asset]market.Kline)
+	}
+	s.assetPrices[asset] = price
+}
+
+// quote returns the latest price for the asset,
+// falling back to the default price feed if the asset has no feed of its own.
+func (s *Simulator) quote(asset market.Asset) market.Kline {
+	if price, ok := s.assetPrices[asset]; ok {
+		return price
+	}
+	return s.marketPrice
 }
 
+// isQuotedBy returns true if the price feed for the given asset is used to match orders in the order asset.
+func (s *Simulator) isQuotedBy(orderAsset, feedAsset market.Asset) bool {
+	if orderAsset == feedAsset {
+		return true
+	}
+	_, ok := s.assetPrices[orderAsset]
+	return feedAsset == (market.Asset{}) && !ok
+}
+
+// getPosition returns the latest position for the asset, or an empty position if it is closed.
+// In Hedge mode the latest position on the given side is returned, otherwise the side is ignored.
+func (s *Simulator) getPosition(asset market.Asset, side broker.OrderSide) broker.Position {
+	var empty broker.Position
+	indices := s.assetPositions[asset]
+	for j := len(indices) - 1; j >= 0; j-- {
+		position := s.positions[indices[j]]
+		if s.mode == Hedge && position.Side != side {
+			continue
+		}
+		if position.State() == broker.PositionClosed {
+			return empty
+		}
+		return position
+	}
+	return empty
+}
+
+// upsertPosition updates the position with the same ID or appends a new position, and maintains the position indices.
 func (s *Simulator) upsertPosition(position broker.Position) {
-	if len(s.positions) == 0 {
+	i, ok := s.findPosition(position)
+	if ok {
+		s.positions[i] = position
+	} else {
+		i = len(s.positions)
 		s.positions = append(s.positions, position)
-		return
+		s.assetPositions[position.Asset] = append(s.assetPositions[position.Asset], i)
 	}
-	last := s.positions[len(s.positions)-1]
-	if position.ID == last.ID {
-		s.positions[len(s.positions)-1] = position
-		return
+
+	j := slices.Index(s.openPositions, i)
+	switch {
+	case position.State() == broker.PositionOpen && j < 0:
+		s.openPositions = append(s.openPositions, i)
+	case position.State() != broker.PositionOpen && j >= 0:
+		s.openPositions = slices.Delete(s.openPositions, j, j+1)
+	}
+}
+
+// findPosition returns the index of the position with the same ID, searching the positions of its asset.
+func (s *Simulator) findPosition(position broker.Position) (int, bool) {
+	indices := s.assetPositions[position.Asset]
+	for j := len(indices) - 1; j >= 0; j-- {
+		if s.positions[indices[j]].ID == position.ID {
+			return indices[j], true
+		}
+	}
+	return 0, false
+}
+
+// indexPositions rebuilds the position indices from the position history.
+func (s *Simulator) indexPositions() {
+	s.openPositions = nil
+	s.assetPositions = make(map[market.Asset][]int)
+	for i := range s.positions {
+		s.assetPositions[s.positions[i].Asset] = append(s.assetPositions[s.positions[i].Asset], i)
+		if s.positions[i].State() == broker.PositionOpen {
+			s.openPositions = append(s.openPositions, i)
+		}
 	}
-	s.positions = append(s.positions, position)
 }
 
 func (s *Simulator) processPosition(position broker.Position, order broker.Order) (broker.Position, error) {
@@ -280,32 +1383,30 @@ func (s *Simulator) processPosition(position broker.Position, order broker.Order
 
 		// State transition condition:
 		// Do not open a new position with a 'reduce-only' order
-		// Reduce-only is typically used for stop loss orders and is only permitted to close a position
-		if order.ReduceOnly {
-			return position, ErrRejectedOrder
+		if reason := s.fillRejectReason(position, order); reason != "" {
+			return position, s.rejectOrder(order, reason)
 		}
 
 		// Transition to open
 		position = s.openPosition(order)
-		if position, err = s.processPosition(position, order); err != nil {
-			return position, err
-		}
+		s.emit(Event{Type: PositionOpened, Position: &position})
 
 	case broker.PositionOpen:
 
 		// State transition condition:
-		// If processing the order that opened the position then do not attempt to close position
-		if order.ID == position.ID {
-			break
+		// A new order can only adjust down an opened position to zero, it cannot be forced negative,
+		// unless reversal is enabled in which case the position is closed and the excess opens the opposite position
+		if reason := s.fillRejectReason(position, order); reason != "" {
+			return position, s.rejectOrder(order, reason)
 		}
-
-		// State transition condition:
-		// A new order can only adjust down an opened position to zero, it cannot be forced negative
 		if order.Side == position.Side.Opposite() && order.FilledSize.GreaterThan(position.Size) {
-			return position, ErrRejectedOrder
+			return s.reversePosition(position, order)
 		}
 
 		position = s.adjustPosition(position, order)
+		if position.Size.IsPositive() {
+			s.emit(Event{Type: PositionAdjusted, Position: &position})
+		}
 
 		// Closed position
 		if position.Size.IsZero() {
@@ -317,49 +1418,149 @@ func (s *Simulator) processPosition(position broker.Position, order broker.Order
 
 	case broker.PositionClosed:
 		// Create a round-turn for the closed position
-		// Realize the position PNL to the account balance
+		// Realize the position PNL to the account balance in the quote currency of the asset
 		// Mark price is the fill price of the order that closed the position,
 		// note this is to accommodate the case when a limit order has closed the position
 		position = markPositionToMarket(position, order.FilledPrice)
 		roundturn := s.createRoundTurn(position)
-		s.balance.Trade = s.balance.Trade.Add(roundturn.Profit)
+		s.credit(s.quoteCurrency(position.Asset), roundturn.Profit)
 		s.roundturns = append(s.roundturns, roundturn)
+		s.emit(Event{Type: PositionClosed, Position: &position})
 	}
 
 	return position, nil
 }
 
+// fillRejectReason returns the reason the position rejects the fill of an order, or empty if the fill is accepted.
+func (s *Simulator) fillRejectReason(position broker.Position, fill broker.Order) string {
+	switch position.State() {
+	case broker.PositionPending:
+		// Reduce-only is typically used for stop loss orders and is only permitted to close a position,
+		// in Hedge mode orders opposite to their position side are implicitly reduce-only
+		if fill.ReduceOnly || (s.mode == Hedge && fill.Side != fill.PositionSide) {
+			return "reduce-only order cannot open a position"
+		}
+	case broker.PositionOpen:
+		if fill.Side == position.Side.Opposite() && fill.FilledSize.GreaterThan(position.Size) && !s.canReverse(fill) {
+			return "order size exceeds position size"
+		}
+	}
+	return ""
+}
+
+// canReverse returns true if the order is permitted to reverse a position.
+func (s *Simulator) canReverse(order broker.Order) bool {
+	return s.reversal && s.mode == OneWay && !order.ReduceOnly
+}
+
+// reversePosition closes the position with the part of the order fill equal to the position size,
+// and opens the opposite position with the remainder. The fill fee is split pro rata to size.
+// Returns the new position, the closed position is stored.
+func (s *Simulator) reversePosition(position broker.Position, order broker.Order) (broker.Position, error) {
+	closing, opening := order, order
+	closing.FilledSize = position.Size
+	closing.Fee = order.Fee.Mul(position.Size).Div(order.FilledSize)
+	opening.FilledSize = order.FilledSize.Sub(position.Size)
+	opening.Fee = order.Fee.Sub(closing.Fee)
+
+	position, err := s.processPosition(position, closing)
+	if err != nil {
+		return position, err
+	}
+	s.upsertPosition(position)
+
+	return s.processPosition(broker.Position{}, opening)
+}
+
+// openPosition opens a new position from the order fill.
+// Each position has a new ID, as an order filled in parts can close and then reopen a position.
 func (s *Simulator) openPosition(order broker.Order) broker.Position {
 	position := broker.Position{
-		ID:       order.ID,
+		ID:       broker.NewIDWithTime(s.clock.Now()),
 		OpenedAt: order.FilledAt,
 		Asset:    order.Asset,
 		Side:     order.Side,
 	}
+	s.openFunding(s.cost, position)
 
 	return s.adjustPosition(position, order)
 }
 
+// adjustPosition applies an order fill to the position and records the fill.
+// A fill that reduces the position realizes the profit of the reduced size against the entry price, net of the fill fee.
 func (s *Simulator) adjustPosition(position broker.Position, order broker.Order) broker.Position {
 
 	position.TradeCount++
 
 	orderCost := order.FilledSize.Mul(order.FilledPrice)
 
+	sign := decimal.NewFromInt(1)
+	if position.Side == broker.Sell {
+		sign = sign.Neg()
+	}
+	fee := positionFee(position, order.Fee)
+
+	var reduced bool
+	var realized decimal.Decimal
 	switch position.Side {
 	case order.Side:
-		position.Cost = position.Cost.Add(orderCost).Add(order.Fee)
+		position.Cost = position.Cost.Add(orderCost).Add(fee)
 		position.Size = position.Size.Add(order.FilledSize)
 	case order.Side.Opposite():
-		position.Cost = position.Cost.Sub(orderCost).Add(order.Fee)
+		reduced = true
+		realized = order.FilledPrice.Sub(position.EntryPrice).Mul(order.FilledSize).Mul(sign).Sub(order.Fee)
+		position.RealizedPNL = position.RealizedPNL.Add(realized)
+		position.Cost = position.Cost.Sub(orderCost).Add(fee)
 		position.Size = position.Size.Sub(order.FilledSize)
 	}
 
-	position.EntryPrice = position.Cost.Div(dec.NZ(position.Size, dec.New(1))).Abs()
+	// Entry price is the cost of the remaining size, excluding the cost of the size already closed
+	if position.Size.IsPositive() {
+		position.EntryPrice = position.Cost.Add(position.RealizedPNL.Mul(sign)).Div(position.Size)
+	}
+	// Re-mark a reduced position so that the unrealized PNL excludes the profit realized by the fill
+	if reduced {
+		position = markPositionToMarket(position, dec.NZ(position.MarkPrice, order.FilledPrice))
+	}
+
+	s.fills = append(s.fills, broker.Fill{
+		ID:          broker.NewIDWithTime(order.FilledAt),
+		OrderID:     order.ID,
+		PositionID:  position.ID,
+		FilledAt:    order.FilledAt,
+		Asset:       order.Asset,
+		Side:        order.Side,
+		Price:       order.FilledPrice,
+		Size:        order.FilledSize,
+		Fee:         order.Fee,
+		Liquidity:   fillLiquidity(order),
+		RealizedPNL: realized,
+	})
 
 	return position
 }
 
+// positionFee returns the fee as an amount added to the position cost.
+// Fees always reduce PNL, as short PNL is the inverse of cost the fee is deducted from the cost of a short.
+func positionFee(position broker.Position, fee decimal.Decimal) decimal.Decimal {
+	if position.Side == broker.Sell {
+		return fee.Neg()
+	}
+	return fee
+}
+
+// fillLiquidity returns the Liquidity of the order fill as matched by the simulator.
+// Orders without a matched liquidity are Maker for Limit orders, otherwise Taker.
+func fillLiquidity(order broker.Order) broker.Liquidity {
+	if order.Liquidity != 0 {
+		return order.Liquidity
+	}
+	if order.Type == broker.Limit {
+		return broker.Maker
+	}
+	return broker.Taker
+}
+
 func (s *Simulator) closePosition(position broker.Position, order broker.Order) broker.Position {
 	position.ClosedAt = order.FilledAt
 	position.ExitPrice = order.FilledPrice
@@ -378,12 +1579,23 @@ func (s *Simulator) createRoundTurn(position broker.Position) broker.RoundTurn {
 	}
 }
 
+// positionMargin returns the initial or maintenance margin of the position at its marked price,
+// or the entry price if the position has not yet been marked.
+func positionMargin(position broker.Position, rule MarginRule, maintenance bool) decimal.Decimal {
+	rate := rule.InitialRate()
+	if maintenance {
+		rate = rule.MaintenanceMarginPct
+	}
+	return position.Size.Mul(dec.NZ(position.MarkPrice, position.EntryPrice)).Mul(rate)
+}
+
 func markPositionToMarket(position broker.Position, markPrice decimal.Decimal) broker.Position {
 	position.MarkPrice = markPrice
 	position.PNL = position.Size.Mul(position.MarkPrice).Sub(position.Cost)
 	if position.Side == broker.Sell {
 		position.PNL = position.PNL.Mul(dec.New(-1))
 	}
+	position.UnrealizedPNL = position.PNL.Sub(position.RealizedPNL)
 	return position
 }
 
@@ -391,17 +1603,171 @@ func matchOrder(order broker.Order, quote market.Kline) decimal.Decimal {
 	var matchedPrice decimal.Decimal
 
 	switch order.Type {
-	case broker.Limit:
+	case broker.Limit, broker.StopLimit:
 		if dec.Between(order.LimitPrice, quote.L, quote.H) {
 			matchedPrice = order.LimitPrice
 		}
 	case broker.Market:
 		matchedPrice = quote.C
+	case broker.Stop, broker.TrailingStop:
+		// Fill at the worse of the stop and open price when the price gaps through the stop
+		matchedPrice = order.StopPrice
+		if order.Side == broker.Buy && quote.O.GreaterThan(order.StopPrice) {
+			matchedPrice = quote.O
+		}
+		if order.Side == broker.Sell && quote.O.LessThan(order.StopPrice) {
+			matchedPrice = quote.O
+		}
 	}
 
 	return matchedPrice
 }
 
+// orderPathDistance returns how far along the intrabar path the order price is first traded.
+// Market orders are traded at the close, and orders marketable at the open are traded at the start of the path.
+func orderPathDistance(order broker.Order, path []decimal.Decimal) (decimal.Decimal, bool) {
+	if len(path) == 0 {
+		return decimal.Zero, false
+	}
+	open := path[0]
+
+	switch {
+	case order.Type == broker.Market:
+		return decimal.NewFromInt(int64(len(path) - 1)), true
+	case order.Type.IsStop() && order.TriggeredAt.IsZero():
+		if (order.Side == broker.Buy && open.GreaterThanOrEqual(order.StopPrice)) ||
+			(order.Side == broker.Sell && open.LessThanOrEqual(order.StopPrice)) {
+			return decimal.Zero, true
+		}
+		return pathDistance(path, order.StopPrice)
+	case order.Type == broker.Stop || order.Type == broker.TrailingStop:
+		return decimal.Zero, true
+	default:
+		if isMarketable(order, open) {
+			return decimal.Zero, true
+		}
+		return pathDistance(path, order.LimitPrice)
+	}
+}
+
+// matchImmediateOrder matches an IOC or FOK order in the epoch it is placed.
+// Limit orders are matched at the current close price if marketable, other types match as normal.
+func matchImmediateOrder(order broker.Order, quote market.Kline) decimal.Decimal {
+	if order.Type != broker.Limit {
+		return matchOrder(order, quote)
+	}
+	if isMarketable(order, quote.C) {
+		return quote.C
+	}
+	return decimal.Zero
+}
+
+// matchTradeOrder returns the price an order is matched at by a trade print, or zero if not matched.
+// Limit orders are matched at their limit price by a print at or through the limit
+// from an aggressor on the opposite side, trade prints without a known aggressor match either side.
+func matchTradeOrder(order broker.Order, trade market.Trade) decimal.Decimal {
+	if order.Type != broker.Limit && order.Type != broker.StopLimit {
+		return matchOrder(order, trade.Kline())
+	}
+	if !isMarketable(order, trade.Price) {
+		return decimal.Zero
+	}
+	if (trade.Side == market.TakerBuy && order.Side == broker.Buy) ||
+		(trade.Side == market.TakerSell && order.Side == broker.Sell) {
+		return decimal.Zero
+	}
+	return order.LimitPrice
+}
+
+// isWorking returns true if the order is open, or pending while delayed by latency, and so can be cancelled or amended.
+func isWorking(order broker.Order) bool {
+	state := order.State()
+	return state == broker.OrderOpen || state == broker.OrderPending
+}
+
+// isMarketable returns true if a limit order would fill immediately at the given price as a taker.
+func isMarketable(order broker.Order, price decimal.Decimal) bool {
+	switch order.Side {
+	case broker.Buy:
+		return order.LimitPrice.GreaterThanOrEqual(price)
+	case broker.Sell:
+		return order.LimitPrice.LessThanOrEqual(price)
+	}
+	return false
+}
+
+// isImmediate returns true if the order must be filled in the epoch it is placed.
+func isImmediate(order broker.Order) bool {
+	return order.TimeInForce == broker.IOC || order.TimeInForce == broker.FOK
+}
+
+// trailOrder moves the stop price of a trailing stop order to trail the given best price.
+// The stop price only moves in the favourable direction: up for sell orders and down for buy orders.
+func trailOrder(order broker.Order, best decimal.Decimal) broker.Order {
+	offset := order.TrailAmount
+	if order.TrailPct.IsPositive() {
+		offset = best.Mul(order.TrailPct)
+	}
+
+	switch order.Side {
+	case broker.Buy:
+		stop := best.Add(offset)
+		if order.StopPrice.IsZero() || stop.LessThan(order.StopPrice) {
+			order.StopPrice = stop
+		}
+	case broker.Sell:
+		stop := best.Sub(offset)
+		if stop.GreaterThan(order.StopPrice) {
+			order.StopPrice = stop
+		}
+	}
+
+	return order
+}
+
+// triggerOrder returns true if the stop price of the order is touched by the quote.
+// Buy stops trigger on a rise to the stop price, sell stops on a fall to the stop price.
+func triggerOrder(order broker.Order, quote market.Kline) bool {
+	switch order.Side {
+	case broker.Buy:
+		return quote.H.GreaterThanOrEqual(order.StopPrice)
+	case broker.Sell:
+		return quote.L.LessThanOrEqual(order.StopPrice)
+	}
+	return false
+}
+
+// validateOrder returns ErrInvalidOrderState if the order is not valid for processing.
+func validateOrder(order broker.Order) error {
+	if order.Side == 0 || order.Type == 0 || order.State() != broker.OrderPending || !order.Size.IsPositive() {
+		return ErrInvalidOrderState
+	}
+	if order.Type.IsStop() && order.Type != broker.TrailingStop && !order.StopPrice.IsPositive() {
+		return ErrInvalidOrderState
+	}
+	if order.Type == broker.TrailingStop && !order.TrailAmount.IsPositive() && !order.TrailPct.IsPositive() {
+		return ErrInvalidOrderState
+	}
+	if order.TimeInForce == broker.GTD && order.ExpiresAt.IsZero() {
+		return ErrInvalidOrderState
+	}
+	if order.TimeInForce == broker.PostOnly && order.Type != broker.Limit {
+		return ErrInvalidOrderState
+	}
+	if order.Type == broker.StopLimit && !order.LimitPrice.IsPositive() {
+		return ErrInvalidOrderState
+	}
+	return nil
+}
+
+// isSameEpoch returns true if the given time is in the current epoch,
+// between the start of the epoch and the current clock time.
+// Full timestamps are compared as klines of a day or longer share the same time of day,
+// and trade prints can be milliseconds apart.
+func (s *Simulator) isSameEpoch(t time.Time) bool {
+	return !t.Before(s.epoch) && !t.After(s.clock.Peek())
+}
+
 func equalClock(t1, t2 time.Time) bool {
 	t1H, t1M, t1S := t1.Clock()
 	t2H, t2M, t2S := t2.Clock()
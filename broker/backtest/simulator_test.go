@@ -57,6 +57,15 @@ func TestSimulator_AddOrder(t *testing.T) {
 			},
 			want: ErrInvalidOrderState,
 		},
+		{
+			name: "stop without stop price",
+			give: broker.Order{
+				Side: broker.Sell,
+				Type: broker.Stop,
+				Size: dec.New(1),
+			},
+			want: ErrInvalidOrderState,
+		},
 		{
 			name: "no pending state",
 			give: broker.Order{
@@ -165,10 +174,37 @@ func TestSimulator_fillOrder(t *testing.T) {
 		FilledPrice: dec.New(100),
 		FilledSize:  dec.New(1),
 	}
-	act := sim.fillOrder(broker.Order{Side: broker.Buy, Size: exp.Size}, exp.FilledPrice)
+	act, _ := sim.fillOrder(broker.Order{Side: broker.Buy, Size: exp.Size}, exp.FilledPrice)
 
 	assert.True(t, act.FilledPrice.Equal(exp.FilledPrice))
 	assert.True(t, act.FilledSize.Equal(exp.FilledSize))
+	assert.True(t, act.State() == broker.OrderFilled)
+}
+
+func TestSimulator_fillOrderPartial(t *testing.T) {
+	sim := newSimulatorForTest()
+	sim.SetLiquidity(NewVolumeLiquidity(dec.New(0.5)))
+	sim.marketPrice = market.Kline{Volume: 2}
+
+	give := broker.Order{OpenedAt: _fixed, Side: broker.Buy, Size: dec.New(3)}
+
+	act, fill := sim.fillOrder(give, dec.New(10))
+	assert.True(t, fill.FilledSize.Equal(dec.New(1)))
+	assert.True(t, act.FilledSize.Equal(dec.New(1)))
+	assert.True(t, act.State() == broker.OrderOpen)
+
+	act, fill = sim.fillOrder(act, dec.New(20))
+	assert.True(t, fill.FilledSize.Equal(dec.New(1)))
+	assert.True(t, fill.FilledPrice.Equal(dec.New(20)))
+	assert.True(t, act.FilledSize.Equal(dec.New(2)))
+	assert.True(t, act.FilledPrice.Equal(dec.New(15)))
+
+	sim.marketPrice = market.Kline{Volume: 10}
+	act, fill = sim.fillOrder(act, dec.New(40))
+	assert.True(t, fill.FilledSize.Equal(dec.New(1)))
+	assert.True(t, act.FilledSize.Equal(dec.New(3)))
+	assert.True(t, act.FilledPrice.Equal(dec.New(70).Div(dec.New(3))))
+	assert.True(t, act.State() == broker.OrderFilled)
 }
 
 func TestSimulator_closeOrder(t *testing.T) {
@@ -181,10 +217,13 @@ func TestSimulator_closeOrder(t *testing.T) {
 }
 
 func TestSimulator_getPosition(t *testing.T) {
+	btc := market.NewAsset("BTCUSD")
+	eth := market.NewAsset("ETHUSD")
 	tests := []struct {
-		name string
-		give []broker.Position
-		want broker.PositionState
+		name      string
+		give      []broker.Position
+		giveAsset market.Asset
+		want      broker.PositionState
 	}{
 		{
 			name: "no positions",
@@ -204,12 +243,32 @@ func TestSimulator_getPosition(t *testing.T) {
 			give: []broker.Position{{ID: "1", OpenedAt: _fixed}},
 			want: broker.PositionOpen,
 		},
+		{
+			name: "latest position for asset is open",
+			give: []broker.Position{
+				{ID: "1", OpenedAt: _fixed, Asset: btc},
+				{ID: "2", OpenedAt: _fixed, ClosedAt: _fixed, Asset: eth},
+			},
+			giveAsset: btc,
+			want:      broker.PositionOpen,
+		},
+		{
+			name: "latest position for asset is closed",
+			give: []broker.Position{
+				{ID: "1", OpenedAt: _fixed, Asset: btc},
+				{ID: "2", OpenedAt: _fixed, ClosedAt: _fixed, Asset: eth},
+			},
+			giveAsset: eth,
+			want:      broker.PositionPending,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			sim := newSimulatorForTest()
-			sim.positions = tt.give
-			act := sim.getPosition()
+			for _, position := range tt.give {
+				sim.upsertPosition(position)
+			}
+			act := sim.getPosition(tt.giveAsset, 0)
 			assert.Equal(t, tt.want, act.State())
 		})
 	}
@@ -229,7 +288,7 @@ func TestSimulator_processPosition(t *testing.T) {
 			giveOrder:    broker.Order{ID: "1", Side: broker.Buy, FilledAt: _fixed, FilledPrice: dec.New(10), FilledSize: dec.New(1)},
 			givePosition: broker.Position{},
 			wantPosition: broker.Position{
-				ID:         "1",
+				ID:         broker.NewIDWithTime(_fixed),
 				OpenedAt:   _fixed,
 				Side:       broker.Buy,
 				EntryPrice: dec.New(10),
@@ -245,17 +304,18 @@ func TestSimulator_processPosition(t *testing.T) {
 			giveOrder:    broker.Order{ID: "2", FilledAt: _fixed, Side: broker.Sell, FilledPrice: dec.New(20), FilledSize: dec.New(1)},
 			givePosition: broker.Position{ID: "1", OpenedAt: _fixed, Side: broker.Buy, Cost: dec.New(10), EntryPrice: dec.New(10), Size: dec.New(1)},
 			wantPosition: broker.Position{
-				ID:         "1",
-				OpenedAt:   _fixed,
-				ClosedAt:   _fixed,
-				Side:       broker.Buy,
-				Cost:       dec.New(-10),
-				EntryPrice: dec.New(10),
-				Size:       dec.New(0),
-				ExitPrice:  dec.New(20),
-				MarkPrice:  dec.New(20),
-				PNL:        dec.New(10),
-				TradeCount: 1,
+				ID:          "1",
+				OpenedAt:    _fixed,
+				ClosedAt:    _fixed,
+				Side:        broker.Buy,
+				Cost:        dec.New(-10),
+				EntryPrice:  dec.New(10),
+				Size:        dec.New(0),
+				ExitPrice:   dec.New(20),
+				MarkPrice:   dec.New(20),
+				PNL:         dec.New(10),
+				RealizedPNL: dec.New(10),
+				TradeCount:  1,
 			},
 			wantState: broker.PositionClosed,
 			wantErr:   nil,
@@ -289,7 +349,7 @@ func TestSimulator_processPosition(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			sim := newSimulatorForTest()
 			act, err := sim.processPosition(tt.givePosition, tt.giveOrder)
-			assert.Equal(t, tt.wantErr, err)
+			assert.ErrorIs(t, err, tt.wantErr)
 			test.EqualApprox(t, tt.wantPosition, act, 0.01)
 			assert.Equal(t, tt.wantState, act.State())
 		})
@@ -302,7 +362,7 @@ func TestSimulator_openPosition(t *testing.T) {
 	sim.marketPrice = market.Kline{C: dec.New(10)}
 
 	exp := broker.Position{
-		ID:         "1",
+		ID:         broker.NewIDWithTime(_fixed),
 		OpenedAt:   _fixed,
 		Asset:      market.NewAsset("BTCUSD"),
 		Side:       broker.Buy,
@@ -313,7 +373,7 @@ func TestSimulator_openPosition(t *testing.T) {
 	}
 
 	act := sim.openPosition(broker.Order{
-		ID:          exp.ID,
+		ID:          "1",
 		FilledAt:    _fixed,
 		Asset:       exp.Asset,
 		Side:        exp.Side,
@@ -422,6 +482,47 @@ func TestSimulator_matchOrder(t *testing.T) {
 			giveQuote: market.Kline{O: dec.New(8), H: dec.New(15), L: dec.New(5), C: dec.New(10)},
 			want:      decimal.Decimal{},
 		},
+		{
+			name: "match stop at stop price",
+			giveOrder: broker.Order{
+				Type:      broker.Stop,
+				Side:      broker.Sell,
+				StopPrice: dec.New(6),
+			},
+			giveQuote: market.Kline{O: dec.New(8), H: dec.New(15), L: dec.New(5), C: dec.New(10)},
+			want:      dec.New(6),
+		},
+		{
+			name: "match sell stop at open when price gaps down",
+			giveOrder: broker.Order{
+				Type:      broker.Stop,
+				Side:      broker.Sell,
+				StopPrice: dec.New(9),
+			},
+			giveQuote: market.Kline{O: dec.New(8), H: dec.New(15), L: dec.New(5), C: dec.New(10)},
+			want:      dec.New(8),
+		},
+		{
+			name: "match buy stop at open when price gaps up",
+			giveOrder: broker.Order{
+				Type:      broker.Stop,
+				Side:      broker.Buy,
+				StopPrice: dec.New(7),
+			},
+			giveQuote: market.Kline{O: dec.New(8), H: dec.New(15), L: dec.New(5), C: dec.New(10)},
+			want:      dec.New(8),
+		},
+		{
+			name: "match stop limit at limit price",
+			giveOrder: broker.Order{
+				Type:       broker.StopLimit,
+				Side:       broker.Sell,
+				StopPrice:  dec.New(9),
+				LimitPrice: dec.New(7),
+			},
+			giveQuote: market.Kline{O: dec.New(8), H: dec.New(15), L: dec.New(5), C: dec.New(10)},
+			want:      dec.New(7),
+		},
 		{
 			name: "always match market on latest close price",
 			giveOrder: broker.Order{
@@ -439,6 +540,93 @@ func TestSimulator_matchOrder(t *testing.T) {
 	}
 }
 
+func TestSimulator_trailOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		giveOrder broker.Order
+		giveBest  decimal.Decimal
+		want      decimal.Decimal
+	}{
+		{
+			name:      "sell initial stop by amount",
+			giveOrder: broker.Order{Side: broker.Sell, TrailAmount: dec.New(2)},
+			giveBest:  dec.New(10),
+			want:      dec.New(8),
+		},
+		{
+			name:      "sell stop moves up",
+			giveOrder: broker.Order{Side: broker.Sell, TrailAmount: dec.New(2), StopPrice: dec.New(8)},
+			giveBest:  dec.New(12),
+			want:      dec.New(10),
+		},
+		{
+			name:      "sell stop does not move down",
+			giveOrder: broker.Order{Side: broker.Sell, TrailAmount: dec.New(2), StopPrice: dec.New(8)},
+			giveBest:  dec.New(9),
+			want:      dec.New(8),
+		},
+		{
+			name:      "buy initial stop by pct",
+			giveOrder: broker.Order{Side: broker.Buy, TrailPct: dec.New(0.1)},
+			giveBest:  dec.New(10),
+			want:      dec.New(11),
+		},
+		{
+			name:      "buy stop moves down",
+			giveOrder: broker.Order{Side: broker.Buy, TrailPct: dec.New(0.1), StopPrice: dec.New(11)},
+			giveBest:  dec.New(5),
+			want:      dec.New(5.5),
+		},
+		{
+			name:      "buy stop does not move up",
+			giveOrder: broker.Order{Side: broker.Buy, TrailPct: dec.New(0.1), StopPrice: dec.New(11)},
+			giveBest:  dec.New(20),
+			want:      dec.New(11),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := trailOrder(tt.giveOrder, tt.giveBest)
+			assert.True(t, act.StopPrice.Equal(tt.want))
+		})
+	}
+}
+
+func TestSimulator_triggerOrder(t *testing.T) {
+	quote := market.Kline{O: dec.New(8), H: dec.New(15), L: dec.New(5), C: dec.New(10)}
+	tests := []struct {
+		name string
+		give broker.Order
+		want bool
+	}{
+		{
+			name: "buy stop triggered",
+			give: broker.Order{Side: broker.Buy, StopPrice: dec.New(15)},
+			want: true,
+		},
+		{
+			name: "buy stop not triggered",
+			give: broker.Order{Side: broker.Buy, StopPrice: dec.New(16)},
+			want: false,
+		},
+		{
+			name: "sell stop triggered",
+			give: broker.Order{Side: broker.Sell, StopPrice: dec.New(5)},
+			want: true,
+		},
+		{
+			name: "sell stop not triggered",
+			give: broker.Order{Side: broker.Sell, StopPrice: dec.New(4)},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, triggerOrder(tt.give, quote))
+		})
+	}
+}
+
 func TestSimulator_positionMarkToMarket(t *testing.T) {
 
 	tests := []struct {
@@ -0,0 +1,159 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+// simulatorSnapshot is the serializable state of a Simulator.
+type simulatorSnapshot struct {
+	Balance     broker.AccountBalance
+	Cash        map[string]decimal.Decimal
+	Rates       []market.ConversionRate
+	MarketPrice market.Kline
+	AssetPrices []assetPrice
+	Epoch       time.Time
+	LiveAt      map[broker.DealID]time.Time
+	Orders      []broker.Order
+	Positions   []broker.Position
+	RoundTurns  []broker.RoundTurn
+	Fills       []broker.Fill
+	Equity      broker.EquitySeries
+	Books       []assetBook
+	Clock       json.RawMessage
+	Cost        json.RawMessage
+	Latency     json.RawMessage `json:",omitempty"`
+}
+
+// assetPrice is the latest price of an asset, as a map keyed by asset cannot be serialized to JSON.
+type assetPrice struct {
+	Asset market.Asset
+	Price market.Kline
+}
+
+// assetBook is the order book of an asset, with the depth taken by fills removed.
+type assetBook struct {
+	Asset market.Asset
+	Book  *market.OrderBook
+}
+
+// Snapshot serializes the simulator state to JSON:
+// balance, orders, positions, round-turns, fills, equity, latest prices, conversion rates, order books,
+// clock, cost model and latency model state.
+// The clock, cost and latency models are serialized with encoding/json so must implement json.Marshaler
+// to include unexported state.
+// Configuration (liquidity, margin, intrabar path, lower timeframe feeds and subscribers) is not included.
+func (s *Simulator) Snapshot() ([]byte, error) {
+	clock, err := json.Marshal(s.clock)
+	if err != nil {
+		return nil, err
+	}
+	cost, err := json.Marshal(s.cost)
+	if err != nil {
+		return nil, err
+	}
+	var latency []byte
+	if s.latency != nil {
+		if latency, err = json.Marshal(s.latency); err != nil {
+			return nil, err
+		}
+	}
+
+	snapshot := simulatorSnapshot{
+		Balance:     s.balance,
+		Cash:        s.cash,
+		MarketPrice: s.marketPrice,
+		Epoch:       s.epoch,
+		LiveAt:      s.liveAt,
+		Orders:      s.orders,
+		Positions:   s.positions,
+		RoundTurns:  s.roundturns,
+		Fills:       s.fills,
+		Equity:      s.equity,
+		Clock:       clock,
+		Cost:        cost,
+		Latency:     latency,
+	}
+	if s.converter != nil {
+		snapshot.Rates = s.converter.Rates()
+	}
+	for asset, price := range s.assetPrices {
+		snapshot.AssetPrices = append(snapshot.AssetPrices, assetPrice{Asset: asset, Price: price})
+	}
+	// Sort for a deterministic snapshot
+	sort.Slice(snapshot.AssetPrices, func(i, j int) bool {
+		return snapshot.AssetPrices[i].Asset.Symbol < snapshot.AssetPrices[j].Asset.Symbol
+	})
+	for asset, book := range s.books {
+		snapshot.Books = append(snapshot.Books, assetBook{Asset: asset, Book: book})
+	}
+	sort.Slice(snapshot.Books, func(i, j int) bool {
+		return snapshot.Books[i].Asset.Symbol < snapshot.Books[j].Asset.Symbol
+	})
+
+	return json.Marshal(snapshot)
+}
+
+// Restore replaces the simulator state with a snapshot output by Snapshot,
+// continuing from the restored state is identical to continuing the snapshotted simulator.
+// Configuration is not restored, so configure the simulator the same as the snapshotted simulator,
+// including the latency model which has its state restored from the snapshot.
+// Order books are restored from the snapshot, replacing any set on the simulator.
+func (s *Simulator) Restore(data []byte) error {
+	var snapshot simulatorSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(snapshot.Clock, s.clock); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(snapshot.Cost, s.cost); err != nil {
+		return err
+	}
+	if s.latency != nil && snapshot.Latency != nil {
+		if err := json.Unmarshal(snapshot.Latency, s.latency); err != nil {
+			return err
+		}
+	}
+
+	s.balance = snapshot.Balance
+	s.cash = snapshot.Cash
+	if s.converter != nil {
+		if s.cash == nil {
+			s.cash = make(map[string]decimal.Decimal)
+		}
+		for _, rate := range snapshot.Rates {
+			s.converter.SetRate(rate.Pair, rate.Rate)
+		}
+	}
+	s.marketPrice = snapshot.MarketPrice
+	s.epoch = snapshot.Epoch
+	s.liveAt = snapshot.LiveAt
+	s.orders = snapshot.Orders
+	s.positions = snapshot.Positions
+	s.indexPositions()
+	s.roundturns = snapshot.RoundTurns
+	s.fills = snapshot.Fills
+	s.equity = snapshot.Equity
+	if s.equity == nil {
+		s.equity = make(broker.EquitySeries)
+	}
+	s.assetPrices = make(map[market.Asset]market.Kline, len(snapshot.AssetPrices))
+	for _, price := range snapshot.AssetPrices {
+		s.assetPrices[price.Asset] = price.Price
+	}
+	s.books = make(map[market.Asset]*market.OrderBook, len(snapshot.Books))
+	for _, book := range snapshot.Books {
+		s.books[book.Asset] = book.Book
+	}
+
+	return nil
+}
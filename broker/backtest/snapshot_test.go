@@ -0,0 +1,126 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestSimulatorSnapshotRestore(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	btc, eth := market.NewAsset("BTC"), market.NewAsset("ETH")
+	prices := []market.Kline{
+		{Start: start.Add(0 * time.Hour), O: dec.New(10), H: dec.New(12), L: dec.New(9), C: dec.New(10)},
+		{Start: start.Add(1 * time.Hour), O: dec.New(10), H: dec.New(16), L: dec.New(9), C: dec.New(14)},
+		{Start: start.Add(2 * time.Hour), O: dec.New(14), H: dec.New(15), L: dec.New(8), C: dec.New(9)},
+		{Start: start.Add(3 * time.Hour), O: dec.New(9), H: dec.New(11), L: dec.New(7), C: dec.New(10)},
+	}
+	newSim := func() *Simulator {
+		return NewSimulatorWithCost(&PerpCoster{
+			SpreadPct:      dec.New(0.001),
+			TransactionPct: dec.New(0.001),
+			FundingHourPct: dec.New(0.0001),
+		})
+	}
+
+	sim := newSim()
+	sim.SetInitialCapital(dec.New(1000))
+	for i, price := range prices[:2] {
+		assert.NoError(t, sim.NextAsset(btc, price))
+		assert.NoError(t, sim.NextAsset(eth, price))
+		if i == 0 {
+			_, err := sim.AddOrder(broker.NewOrder(btc, broker.Buy, dec.New(2)))
+			assert.NoError(t, err)
+			_, err = sim.AddOrder(broker.Order{Asset: eth, Side: broker.Sell, Type: broker.Stop, StopPrice: dec.New(8), Size: dec.New(1)})
+			assert.NoError(t, err)
+		}
+	}
+
+	data, err := sim.Snapshot()
+	assert.NoError(t, err)
+	restored := newSim()
+	assert.NoError(t, restored.Restore(data))
+	actData, err := restored.Snapshot()
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(data), string(actData))
+
+	// Continuation of the restored simulator is identical to the original
+	for _, s := range []*Simulator{sim, restored} {
+		for _, price := range prices[2:] {
+			assert.NoError(t, s.NextAsset(btc, price))
+			assert.NoError(t, s.NextAsset(eth, price))
+		}
+	}
+	exp, err := sim.Snapshot()
+	assert.NoError(t, err)
+	act, err := restored.Snapshot()
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(exp), string(act))
+	assert.Len(t, restored.Positions(), 2)
+	assert.True(t, restored.Balance().Equity.Equal(sim.Balance().Equity))
+}
+
+func TestSimulatorSnapshotRestoreLatencyAndOrderBook(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	var prices []market.Kline
+	for i := 0; i < 6; i++ {
+		prices = append(prices, market.Kline{Start: start.Add(time.Duration(i) * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)})
+	}
+	newSim := func() *Simulator {
+		sim := NewSimulator()
+		sim.SetLatency(NewUniformLatency(0, 3*time.Hour, 1))
+		return sim
+	}
+	step := func(sim *Simulator, i int) {
+		assert.NoError(t, sim.Next(prices[i]))
+		if i%2 == 0 {
+			_, err := sim.AddOrder(broker.NewOrder(market.Asset{}, broker.Buy, dec.New(1.5)))
+			assert.NoError(t, err)
+		}
+	}
+	book := market.NewOrderBook(market.DepthUpdate{
+		Asks: []market.BookLevel{{Price: dec.New(10), Size: dec.New(1)}, {Price: dec.New(11), Size: dec.New(1)}, {Price: dec.New(12), Size: dec.New(10)}},
+	})
+
+	straight := newSim()
+	straight.SetInitialCapital(dec.New(1000))
+	straight.SetOrderBook(market.Asset{}, book)
+	for i := range prices {
+		step(straight, i)
+	}
+
+	sim := newSim()
+	sim.SetInitialCapital(dec.New(1000))
+	sim.SetOrderBook(market.Asset{}, book)
+	for i := range prices[:3] {
+		step(sim, i)
+	}
+	data, err := sim.Snapshot()
+	assert.NoError(t, err)
+	restored := newSim()
+	assert.NoError(t, restored.Restore(data))
+	for i := range prices[3:] {
+		step(restored, i+3)
+	}
+
+	// The restored run draws the same delays and fills against the same remaining depth
+	exp, err := straight.Snapshot()
+	assert.NoError(t, err)
+	act, err := restored.Snapshot()
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(exp), string(act))
+	assert.NotEmpty(t, straight.Fills())
+	assert.Len(t, restored.Fills(), len(straight.Fills()))
+}
+
+func TestSimulatorRestoreInvalid(t *testing.T) {
+	sim := NewSimulator()
+	assert.Error(t, sim.Restore([]byte("{")))
+}
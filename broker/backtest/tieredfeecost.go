@@ -0,0 +1,143 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+)
+
+var _ Coster = (*TieredFeeCoster)(nil)
+
+// DefaultFeeWindow is the default rolling window of traded notional used to select a fee tier.
+const DefaultFeeWindow = 30 * 24 * time.Hour
+
+// FeeTier is the maker and taker fee rates applied once the rolling traded notional reaches MinNotional.
+// A negative rate is a rebate.
+type FeeTier struct {
+	MinNotional decimal.Decimal
+	MakerPct    decimal.Decimal
+	TakerPct    decimal.Decimal
+}
+
+// TieredFeeCoster implements the Coster interface with separate maker and taker transaction fees,
+// selected from volume based tiers by the notional traded over a rolling window.
+// Fills of resting Limit and StopLimit orders are charged the maker rate, all other fills the taker rate,
+// including limit orders that cross the market on arrival.
+// Spread, slippage and funding are costed by the embedded PerpCoster, its TransactionPct is not used.
+type TieredFeeCoster struct {
+	PerpCoster
+
+	// Tiers are sorted by MinNotional, the first tier applies below the MinNotional of the second.
+	Tiers []FeeTier
+
+	// Window is the duration of traded notional used to select the tier, defaults to DefaultFeeWindow.
+	Window time.Duration
+
+	fills []notionalFill
+}
+
+// notionalFill is the notional value of a fill at the time it was filled.
+type notionalFill struct {
+	Time     time.Time
+	Notional decimal.Decimal
+}
+
+// NewTieredFeeCoster creates a new TieredFeeCoster with the given tiers and the default window.
+func NewTieredFeeCoster(tiers ...FeeTier) *TieredFeeCoster {
+	sorted := make([]FeeTier, len(tiers))
+	copy(sorted, tiers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].MinNotional.LessThan(sorted[j].MinNotional)
+	})
+	return &TieredFeeCoster{
+		Tiers:  sorted,
+		Window: DefaultFeeWindow,
+	}
+}
+
+// Transaction returns the maker or taker fee of the tier selected by the rolling traded notional prior to the fill,
+// and adds the fill to the rolling notional.
+func (c *TieredFeeCoster) Transaction(order broker.Order) decimal.Decimal {
+	notional := order.FilledPrice.Mul(order.FilledSize)
+	tier := c.Tier(order.FilledAt)
+
+	rate := tier.TakerPct
+	if fillLiquidity(order) == broker.Maker {
+		rate = tier.MakerPct
+	}
+
+	c.fills = append(c.fills, notionalFill{Time: order.FilledAt, Notional: notional})
+
+	return notional.Mul(rate)
+}
+
+// Tier returns the fee tier for the notional traded in the window before the given time.
+func (c *TieredFeeCoster) Tier(at time.Time) FeeTier {
+	rolling := c.RollingNotional(at)
+	var tier FeeTier
+	for i := range c.Tiers {
+		if i > 0 && rolling.LessThan(c.Tiers[i].MinNotional) {
+			break
+		}
+		tier = c.Tiers[i]
+	}
+	return tier
+}
+
+// RollingNotional returns the notional traded in the window before the given time.
+// Fills outside the window are discarded.
+func (c *TieredFeeCoster) RollingNotional(at time.Time) decimal.Decimal {
+	window := c.Window
+	if window == 0 {
+		window = DefaultFeeWindow
+	}
+	from := at.Add(-window)
+
+	i := 0
+	for i < len(c.fills) && !c.fills[i].Time.After(from) {
+		i++
+	}
+	c.fills = c.fills[i:]
+
+	var total decimal.Decimal
+	for _, fill := range c.fills {
+		total = total.Add(fill.Notional)
+	}
+	return total
+}
+
+// tieredFeeCosterState is the serializable state of a TieredFeeCoster.
+type tieredFeeCosterState struct {
+	Perp   json.RawMessage
+	Tiers  []FeeTier
+	Window time.Duration
+	Fills  []notionalFill
+}
+
+// MarshalJSON includes the rolling notional state so that a Simulator snapshot can be restored.
+func (c *TieredFeeCoster) MarshalJSON() ([]byte, error) {
+	perp, err := c.PerpCoster.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(tieredFeeCosterState{Perp: perp, Tiers: c.Tiers, Window: c.Window, Fills: c.fills})
+}
+
+// UnmarshalJSON restores the state output by MarshalJSON.
+func (c *TieredFeeCoster) UnmarshalJSON(data []byte) error {
+	var state tieredFeeCosterState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if err := c.PerpCoster.UnmarshalJSON(state.Perp); err != nil {
+		return err
+	}
+	c.Tiers, c.Window, c.fills = state.Tiers, state.Window, state.Fills
+	return nil
+}
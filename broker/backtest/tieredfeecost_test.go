@@ -0,0 +1,78 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestTieredFeeCosterTransaction(t *testing.T) {
+	// Test evaluates how the rolling notional mutates with each fill
+	// Same TieredFeeCoster instance is used in all sub tests
+	cost := NewTieredFeeCoster(
+		FeeTier{MinNotional: dec.New(1000), MakerPct: dec.New(-0.0001), TakerPct: dec.New(0.0005)},
+		FeeTier{MinNotional: dec.New(0), MakerPct: dec.New(0.0002), TakerPct: dec.New(0.001)},
+	)
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		give broker.Order
+		want decimal.Decimal
+	}{
+		{
+			name: "taker market fill in base tier",
+			give: broker.Order{Type: broker.Market, FilledAt: start, FilledPrice: dec.New(100), FilledSize: dec.New(6)},
+			want: dec.New(0.6),
+		},
+		{
+			name: "maker limit fill in base tier",
+			give: broker.Order{Type: broker.Limit, FilledAt: start.Add(24 * time.Hour), FilledPrice: dec.New(100), FilledSize: dec.New(4)},
+			want: dec.New(0.08),
+		},
+		{
+			name: "maker rebate in upper tier",
+			give: broker.Order{Type: broker.Limit, FilledAt: start.Add(48 * time.Hour), FilledPrice: dec.New(100), FilledSize: dec.New(10)},
+			want: dec.New(-0.1),
+		},
+		{
+			name: "taker stop fill in upper tier",
+			give: broker.Order{Type: broker.Stop, FilledAt: start.Add(29 * 24 * time.Hour), FilledPrice: dec.New(100), FilledSize: dec.New(1)},
+			want: dec.New(0.05),
+		},
+		{
+			name: "first fills leave window and drop to base tier",
+			give: broker.Order{Type: broker.Market, FilledAt: start.Add(50 * 24 * time.Hour), FilledPrice: dec.New(100), FilledSize: dec.New(1)},
+			want: dec.New(0.1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := cost.Transaction(tt.give)
+			assert.True(t, act.Equal(tt.want), act.String())
+		})
+	}
+}
+
+func TestTieredFeeCosterJSONRoundTrip(t *testing.T) {
+	cost := NewTieredFeeCoster(FeeTier{MakerPct: dec.New(0.0002), TakerPct: dec.New(0.0005)})
+	cost.SpreadPct = dec.New(0.001)
+	cost.Transaction(broker.Order{Type: broker.Market, FilledAt: time.Now(), FilledPrice: dec.New(10), FilledSize: dec.New(2)})
+
+	data, err := json.Marshal(cost)
+	assert.NoError(t, err)
+	var act TieredFeeCoster
+	assert.NoError(t, json.Unmarshal(data, &act))
+	assert.True(t, act.SpreadPct.Equal(cost.SpreadPct))
+	assert.Equal(t, cost.Window, act.Window)
+	assert.True(t, act.RollingNotional(time.Now()).Equal(dec.New(20)))
+}
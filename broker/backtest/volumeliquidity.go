@@ -0,0 +1,32 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+var _ Liquidity = (*VolumeLiquidity)(nil)
+
+// VolumeLiquidity implements the Liquidity interface by capping each fill to a fraction of the kline volume.
+// A kline with zero volume offers no liquidity and the order will remain open.
+type VolumeLiquidity struct {
+	// VolumePct is the maximum fraction of the kline volume an order can take, e.g. 0.1 is 10%.
+	VolumePct decimal.Decimal
+}
+
+// NewVolumeLiquidity creates a new VolumeLiquidity with the given max fraction of volume.
+func NewVolumeLiquidity(pct decimal.Decimal) *VolumeLiquidity {
+	return &VolumeLiquidity{
+		VolumePct: pct,
+	}
+}
+
+// Available returns the fraction of the kline volume available to fill the order.
+func (l *VolumeLiquidity) Available(order broker.Order, price market.Kline) decimal.Decimal {
+	return dec.New(price.Volume).Mul(l.VolumePct)
+}
@@ -0,0 +1,20 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestVolumeLiquidityAvailable(t *testing.T) {
+	liquidity := NewVolumeLiquidity(dec.New(0.1))
+	exp := dec.New(25)
+	act := liquidity.Available(broker.Order{}, market.Kline{Volume: 250})
+	assert.True(t, act.Equal(exp))
+}
@@ -0,0 +1,39 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+var _ Coster = (*zeroCoster)(nil)
+
+// zeroCoster implements the Coster interface without any cost.
+// Cost models that charge a single kind of cost embed it for the others,
+// and are combined with other models using CompositeCoster.
+type zeroCoster struct{}
+
+// Slippage returns zero.
+func (zeroCoster) Slippage(order broker.Order, price decimal.Decimal, kline market.Kline) decimal.Decimal {
+	return decimal.Zero
+}
+
+// Spread returns zero.
+func (zeroCoster) Spread(price decimal.Decimal) decimal.Decimal {
+	return decimal.Zero
+}
+
+// Transaction returns zero.
+func (zeroCoster) Transaction(order broker.Order) decimal.Decimal {
+	return decimal.Zero
+}
+
+// Funding returns zero.
+func (zeroCoster) Funding(position broker.Position, price decimal.Decimal, elapsed time.Duration) decimal.Decimal {
+	return decimal.Zero
+}
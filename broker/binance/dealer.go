@@ -0,0 +1,387 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+// Package binance implements a broker.Dealer for the Binance USD-M futures REST API.
+package binance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/web"
+	"golang.org/x/exp/slices"
+)
+
+// ErrNotSupported is returned for a request that the API has no equivalent for.
+var ErrNotSupported = errors.New("not supported by binance")
+
+// ErrAssetRequired is returned for a list request that the API only supports for a single asset.
+var ErrAssetRequired = errors.New("asset is required by binance")
+
+// ErrInvalidBracket is returned for a bracket order with a leg on the same side as the Enter order.
+var ErrInvalidBracket = errors.New("bracket leg must be opposite the enter side")
+
+const (
+	// DefaultBaseURL is the base URL of the USD-M futures API.
+	DefaultBaseURL = "https://fapi.binance.com"
+
+	// TestnetBaseURL is the base URL of the USD-M futures testnet API.
+	TestnetBaseURL = "https://testnet.binancefuture.com"
+
+	// DefaultWeightLimit is the default request weight limit per minute of the API.
+	DefaultWeightLimit = 2400
+
+	// _listLimit is the maximum number of items returned by a list endpoint.
+	_listLimit = 1000
+)
+
+var _ broker.Dealer = (*Dealer)(nil)
+
+// Dealer implements the broker.Dealer interface for the Binance USD-M futures API.
+// Requests are signed with the API secret using HMAC SHA256, and every web.Response has the request
+// weight rate limit status in Meta.Rate.
+//
+// Orders are identified by symbol and order ID and both are encoded in the order DealID.
+// Bracket orders are placed as separate orders that the API does not link, see PlaceBracketOrder.
+// The API does not support round-turns, ListRoundTurns returns ErrNotSupported.
+// Orders of all states and fills can only be listed for a single asset, set the filter Asset
+// otherwise ErrAssetRequired is returned. Open orders can be listed for all assets.
+// List calls are paginated from the latest 1000 items returned by the API.
+type Dealer struct {
+	apiKey      string
+	secret      string
+	baseURL     string
+	httpClient  *http.Client
+	recvWindow  time.Duration
+	weightLimit int
+	now         func() time.Time
+}
+
+// NewDealer creates a new Dealer for the production API using the given API key and secret.
+func NewDealer(apiKey, secret string) *Dealer {
+	return &Dealer{
+		apiKey:      apiKey,
+		secret:      secret,
+		baseURL:     DefaultBaseURL,
+		httpClient:  http.DefaultClient,
+		recvWindow:  5 * time.Second,
+		weightLimit: DefaultWeightLimit,
+		now:         time.Now,
+	}
+}
+
+// SetBaseURL sets the base URL of the API, e.g. TestnetBaseURL.
+func (d *Dealer) SetBaseURL(baseURL string) {
+	d.baseURL = baseURL
+}
+
+// SetHTTPClient sets the client used to send requests, defaults to http.DefaultClient.
+func (d *Dealer) SetHTTPClient(client *http.Client) {
+	d.httpClient = client
+}
+
+// SetRecvWindow sets how long after its timestamp a request is valid, defaults to 5 seconds.
+func (d *Dealer) SetRecvWindow(window time.Duration) {
+	d.recvWindow = window
+}
+
+// SetWeightLimit sets the request weight limit per minute of the account, defaults to DefaultWeightLimit.
+func (d *Dealer) SetWeightLimit(limit int) {
+	d.weightLimit = limit
+}
+
+// GetBalance returns the futures account balance.
+func (d *Dealer) GetBalance(ctx context.Context) (*broker.AccountBalance, *web.Response, error) {
+	var acc apiAccount
+	res, err := d.do(ctx, http.MethodGet, "/fapi/v2/account", nil, &acc)
+	if err != nil {
+		return nil, res, err
+	}
+	balance := toBalance(acc)
+	return &balance, res, nil
+}
+
+// PlaceOrder places a new order.
+// A TrailingStop order must set TrailPct, a StopPrice sets the price the trailing stop is activated at.
+func (d *Dealer) PlaceOrder(ctx context.Context, order broker.Order) (*broker.Order, *web.Response, error) {
+	params, err := orderParams(order)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.placeOrder(ctx, params)
+}
+
+// placeOrder places a new order with the given request params.
+func (d *Dealer) placeOrder(ctx context.Context, params url.Values) (*broker.Order, *web.Response, error) {
+	params.Set("newOrderRespType", "RESULT")
+	var placed apiOrder
+	res, err := d.do(ctx, http.MethodPost, "/fapi/v1/order", params, &placed)
+	if err != nil {
+		return nil, res, err
+	}
+	out := toOrder(placed)
+	return &out, res, nil
+}
+
+// PlaceBracketOrder places the Enter order followed by each leg as a reduce only order that exits the position.
+// The Stop leg is placed as a STOP_MARKET order triggered at its StopPrice, and the TakeProfit leg as a
+// TAKE_PROFIT_MARKET order triggered at its LimitPrice, or its StopPrice if it is not a Limit order.
+// The size and position side of a leg default to those of the Enter order.
+//
+// The API does not link the orders, so the legs are not one-cancels-other: cancel the remaining leg when the other fills.
+// If a leg fails to be placed the orders already placed are cancelled and the leg error is returned,
+// an Enter order that has already filled cannot be cancelled.
+func (d *Dealer) PlaceBracketOrder(ctx context.Context, bracket broker.BracketOrder) (*broker.BracketOrder, *web.Response, error) {
+	legs := []*broker.Order{&bracket.Stop, &bracket.TakeProfit}
+	legTypes := []string{"STOP_MARKET", "TAKE_PROFIT_MARKET"}
+	legParams := make([]url.Values, len(legs))
+	for i, leg := range legs {
+		if leg.Type == 0 {
+			continue
+		}
+		params, err := bracketLegParams(bracket.Enter, *leg, legTypes[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		legParams[i] = params
+	}
+
+	enter, res, err := d.PlaceOrder(ctx, bracket.Enter)
+	if err != nil {
+		return nil, res, err
+	}
+	bracket.Enter = *enter
+	placed := []broker.DealID{enter.ID}
+
+	for i, leg := range legs {
+		if legParams[i] == nil {
+			continue
+		}
+		order, legRes, err := d.placeOrder(ctx, legParams[i])
+		if err != nil {
+			return nil, legRes, d.cancelPlaced(ctx, placed, err)
+		}
+		order.ParentID = enter.ID
+		*leg = *order
+		placed = append(placed, order.ID)
+		res = legRes
+	}
+	return &bracket, res, nil
+}
+
+// cancelPlaced cancels the orders of a bracket that failed with the given error, latest first.
+// Orders that are no longer open are ignored, any other cancel error is added to the returned error.
+func (d *Dealer) cancelPlaced(ctx context.Context, ids []broker.DealID, cause error) error {
+	for i := len(ids) - 1; i >= 0; i-- {
+		if _, _, err := d.CancelOrder(ctx, ids[i]); err != nil && !errors.Is(err, broker.ErrNotFound) {
+			return fmt.Errorf("%w (cancel %s: %v)", cause, ids[i], err)
+		}
+	}
+	return cause
+}
+
+// CancelOrders cancels all open orders of all assets.
+func (d *Dealer) CancelOrders(ctx context.Context) (*web.Response, error) {
+	var opens []apiOrder
+	res, err := d.do(ctx, http.MethodGet, "/fapi/v1/openOrders", nil, &opens)
+	if err != nil {
+		return res, err
+	}
+
+	var symbols []string
+	for i := range opens {
+		if !slices.Contains(symbols, opens[i].Symbol) {
+			symbols = append(symbols, opens[i].Symbol)
+		}
+	}
+	sort.Strings(symbols)
+
+	for _, symbol := range symbols {
+		params := url.Values{}
+		params.Set("symbol", symbol)
+		if res, err = d.do(ctx, http.MethodDelete, "/fapi/v1/allOpenOrders", params, nil); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// CancelOrder cancels an open order.
+func (d *Dealer) CancelOrder(ctx context.Context, id broker.DealID) (*broker.Order, *web.Response, error) {
+	return d.doOrder(ctx, http.MethodDelete, id, nil)
+}
+
+// AmendOrder amends the limit price and size of an open Limit order, zero value options are not amended.
+// The stop price of an order cannot be amended and returns ErrNotSupported.
+func (d *Dealer) AmendOrder(ctx context.Context, id broker.DealID, opts broker.AmendOpts) (*broker.Order, *web.Response, error) {
+	if !opts.StopPrice.IsZero() {
+		return nil, nil, ErrNotSupported
+	}
+
+	// The API requires the side, size and price to amend an order, so fill the unset options from the order
+	current, res, err := d.GetOrder(ctx, id)
+	if err != nil {
+		return nil, res, err
+	}
+	if opts.LimitPrice.IsZero() {
+		opts.LimitPrice = current.LimitPrice
+	}
+	if opts.Size.IsZero() {
+		opts.Size = current.Size
+	}
+
+	params := url.Values{}
+	params.Set("side", strings.ToUpper(current.Side.String()))
+	params.Set("quantity", opts.Size.String())
+	params.Set("price", opts.LimitPrice.String())
+	return d.doOrder(ctx, http.MethodPut, id, params)
+}
+
+// GetOrder returns the order with the given ID.
+func (d *Dealer) GetOrder(ctx context.Context, id broker.DealID) (*broker.Order, *web.Response, error) {
+	return d.doOrder(ctx, http.MethodGet, id, nil)
+}
+
+// doOrder sends a request to the order endpoint for the order with the given ID.
+func (d *Dealer) doOrder(ctx context.Context, method string, id broker.DealID, params url.Values) (*broker.Order, *web.Response, error) {
+	symbol, orderID, err := parseOrderID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("symbol", symbol)
+	params.Set("orderId", orderID)
+
+	var o apiOrder
+	res, err := d.do(ctx, method, "/fapi/v1/order", params, &o)
+	if err != nil {
+		return nil, res, err
+	}
+	out := toOrder(o)
+	return &out, res, nil
+}
+
+// ListOrders returns the page of orders that match the filter.
+// Orders of an asset are listed from the latest 1000 orders, in any state.
+// Without an asset the filter must select only open orders.
+func (d *Dealer) ListOrders(ctx context.Context, filter *broker.OrderFilter, opts *web.ListOpts) ([]broker.Order, *web.Response, error) {
+	path := "/fapi/v1/openOrders"
+	params := url.Values{}
+	switch {
+	case filter != nil && filter.Asset.Symbol != "":
+		path = "/fapi/v1/allOrders"
+		params.Set("symbol", filter.Asset.Symbol)
+		params.Set("limit", strconv.Itoa(_listLimit))
+		setTimeRange(params, filter.From, filter.To)
+	case filter == nil || len(filter.States) != 1 || filter.States[0] != broker.OrderOpen:
+		return nil, nil, ErrAssetRequired
+	}
+
+	var orders []apiOrder
+	res, err := d.do(ctx, http.MethodGet, path, params, &orders)
+	if err != nil {
+		return nil, res, err
+	}
+
+	var out []broker.Order
+	for i := range orders {
+		o := toOrder(orders[i])
+		if filter.Match(o) {
+			out = append(out, o)
+		}
+	}
+	return paginate(out, res, opts), res, nil
+}
+
+// ListPositions returns the page of open positions that match the filter.
+// Closed positions are not returned by the API.
+func (d *Dealer) ListPositions(ctx context.Context, filter *broker.PositionFilter, opts *web.ListOpts) ([]broker.Position, *web.Response, error) {
+	params := url.Values{}
+	if filter != nil && filter.Asset.Symbol != "" {
+		params.Set("symbol", filter.Asset.Symbol)
+	}
+
+	var positions []apiPosition
+	res, err := d.do(ctx, http.MethodGet, "/fapi/v2/positionRisk", params, &positions)
+	if err != nil {
+		return nil, res, err
+	}
+
+	var out []broker.Position
+	for i := range positions {
+		// Every symbol and position side is returned, including those without a position
+		if positions[i].PositionAmt.IsZero() {
+			continue
+		}
+		p := toPosition(positions[i])
+		if filter.Match(p) {
+			out = append(out, p)
+		}
+	}
+	return paginate(out, res, opts), res, nil
+}
+
+// ListRoundTurns returns ErrNotSupported.
+func (d *Dealer) ListRoundTurns(ctx context.Context, filter *broker.RoundTurnFilter, opts *web.ListOpts) ([]broker.RoundTurn, *web.Response, error) {
+	return nil, nil, ErrNotSupported
+}
+
+// ListFills returns the page of fills of an asset that match the filter, from the latest 1000 fills.
+func (d *Dealer) ListFills(ctx context.Context, filter *broker.FillFilter, opts *web.ListOpts) ([]broker.Fill, *web.Response, error) {
+	if filter == nil || filter.Asset.Symbol == "" {
+		return nil, nil, ErrAssetRequired
+	}
+	params := url.Values{}
+	params.Set("symbol", filter.Asset.Symbol)
+	params.Set("limit", strconv.Itoa(_listLimit))
+	if filter.OrderID != "" {
+		_, orderID, err := parseOrderID(filter.OrderID)
+		if err != nil {
+			return nil, nil, err
+		}
+		params.Set("orderId", orderID)
+	}
+	setTimeRange(params, filter.From, filter.To)
+
+	var trades []apiTrade
+	res, err := d.do(ctx, http.MethodGet, "/fapi/v1/userTrades", params, &trades)
+	if err != nil {
+		return nil, res, err
+	}
+
+	var out []broker.Fill
+	for i := range trades {
+		f := toFill(trades[i])
+		if filter.Match(f) {
+			out = append(out, f)
+		}
+	}
+	return paginate(out, res, opts), res, nil
+}
+
+// setTimeRange sets the start and end time params of the time range [from, to), a zero from or to is not set.
+func setTimeRange(params url.Values, from, to time.Time) {
+	if !from.IsZero() {
+		params.Set("startTime", strconv.FormatInt(from.UnixMilli(), 10))
+	}
+	if !to.IsZero() {
+		// The API end time is inclusive
+		params.Set("endTime", strconv.FormatInt(to.UnixMilli()-1, 10))
+	}
+}
+
+// paginate returns the page of items selected by the list options and sets the page of the response.
+func paginate[T any](items []T, res *web.Response, opts *web.ListOpts) []T {
+	items, res.Meta.Page = web.Paginate(items, opts)
+	return items
+}
@@ -0,0 +1,391 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/alphakit/web"
+	"github.com/thecolngroup/gou/dec"
+)
+
+const (
+	_testAPIKey = "testkey"
+	_testSecret = "testsecret"
+)
+
+var _fixedDate = time.Date(2022, time.July, 1, 12, 30, 15, 0, time.UTC)
+
+// request is a request received by the fixture server.
+type request struct {
+	Method string
+	Path   string
+	Params url.Values
+}
+
+// newDealerForTest returns a Dealer for a fixture server that responds to each "METHOD /path" route
+// with the JSON file of the same name in testdata. A "METHOD /path?type=TYPE" route takes precedence for requests
+// with the given type param. Fixture files prefixed "error_" respond with status 400.
+// Requests are rejected if the API key or signature are invalid, and valid requests are recorded.
+func newDealerForTest(t *testing.T, routes map[string]string) (*Dealer, *[]request) {
+	t.Helper()
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, signature, _ := strings.Cut(r.URL.RawQuery, "&signature=")
+		if r.Header.Get(_headerAPIKey) != _testAPIKey || signature != sign(_testSecret, query) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"code":-1022,"msg":"Signature for this request is not valid."}`))
+			return
+		}
+		requests = append(requests, request{Method: r.Method, Path: r.URL.Path, Params: r.URL.Query()})
+
+		file, ok := routes[r.Method+" "+r.URL.Path+"?type="+r.URL.Query().Get("type")]
+		if !ok {
+			file, ok = routes[r.Method+" "+r.URL.Path]
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, err := os.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set(_headerUsedWeight, "600")
+		w.Header().Set("Date", _fixedDate.Format(http.TimeFormat))
+		if strings.HasPrefix(file, "error_") {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	dealer := NewDealer(_testAPIKey, _testSecret)
+	dealer.SetBaseURL(server.URL)
+	dealer.SetHTTPClient(server.Client())
+	return dealer, &requests
+}
+
+func TestDealer_GetBalance(t *testing.T) {
+	dealer, _ := newDealerForTest(t, map[string]string{"GET /fapi/v2/account": "account.json"})
+
+	act, res, err := dealer.GetBalance(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, act.Trade.Equal(dec.New(1000)))
+	assert.True(t, act.Equity.Equal(dec.New(1025)))
+	assert.True(t, act.MarginUsed.Equal(dec.New(150)))
+	assert.True(t, act.AvailableMargin.Equal(dec.New(875)))
+	assert.Empty(t, act.Currencies)
+	assert.Empty(t, act.Currency)
+
+	wantRate := web.Rate{Limit: 2400, Remaining: 1800, ResetAt: time.Date(2022, time.July, 1, 12, 31, 0, 0, time.UTC)}
+	assert.Equal(t, wantRate.Limit, res.Meta.Rate.Limit)
+	assert.Equal(t, wantRate.Remaining, res.Meta.Rate.Remaining)
+	assert.True(t, wantRate.ResetAt.Equal(res.Meta.Rate.ResetAt), res.Meta.Rate.ResetAt)
+}
+
+func TestDealer_Unauthorized(t *testing.T) {
+	dealer, requests := newDealerForTest(t, map[string]string{"GET /fapi/v2/account": "account.json"})
+	dealer.secret = "wrongsecret"
+
+	_, _, err := dealer.GetBalance(context.Background())
+	var apiErr *APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, -1022, apiErr.Code)
+	assert.Empty(t, *requests)
+}
+
+func TestDealer_PlaceOrder(t *testing.T) {
+	dealer, requests := newDealerForTest(t, map[string]string{"POST /fapi/v1/order": "order_new.json"})
+
+	give := broker.Order{
+		Asset:      market.NewAsset("BTCUSDT"),
+		Side:       broker.Buy,
+		Type:       broker.Limit,
+		LimitPrice: dec.New(19000),
+		Size:       dec.New(0.01),
+	}
+	act, _, err := dealer.PlaceOrder(context.Background(), give)
+	assert.NoError(t, err)
+	assert.Equal(t, broker.DealID("BTCUSDT:22542179"), act.ID)
+	assert.True(t, act.State() == broker.OrderOpen)
+	assert.Equal(t, broker.Limit, act.Type)
+	assert.True(t, act.LimitPrice.Equal(give.LimitPrice))
+
+	params := (*requests)[0].Params
+	assert.Equal(t, "BTCUSDT", params.Get("symbol"))
+	assert.Equal(t, "BUY", params.Get("side"))
+	assert.Equal(t, "LIMIT", params.Get("type"))
+	assert.Equal(t, "GTC", params.Get("timeInForce"))
+	assert.Equal(t, "19000", params.Get("price"))
+	assert.Equal(t, "0.01", params.Get("quantity"))
+	assert.Equal(t, "5000", params.Get("recvWindow"))
+}
+
+func TestDealer_PlaceBracketOrder(t *testing.T) {
+	give := broker.BracketOrder{
+		Enter:      broker.Order{Asset: market.NewAsset("BTCUSDT"), Side: broker.Buy, Type: broker.Limit, LimitPrice: dec.New(19000), Size: dec.New(0.01)},
+		Stop:       broker.Order{Side: broker.Sell, Type: broker.Stop, StopPrice: dec.New(18000)},
+		TakeProfit: broker.Order{Side: broker.Sell, Type: broker.Limit, LimitPrice: dec.New(20000)},
+	}
+
+	t.Run("legs placed as reduce only trigger orders", func(t *testing.T) {
+		dealer, requests := newDealerForTest(t, map[string]string{
+			"POST /fapi/v1/order":                         "order_new.json",
+			"POST /fapi/v1/order?type=STOP_MARKET":        "order_stop_new.json",
+			"POST /fapi/v1/order?type=TAKE_PROFIT_MARKET": "order_take_profit_new.json",
+		})
+		act, _, err := dealer.PlaceBracketOrder(context.Background(), give)
+		assert.NoError(t, err)
+		assert.Equal(t, broker.DealID("BTCUSDT:22542179"), act.Enter.ID)
+		assert.Equal(t, broker.DealID("BTCUSDT:22542181"), act.Stop.ID)
+		assert.Equal(t, act.Enter.ID, act.Stop.ParentID)
+		assert.Equal(t, broker.DealID("BTCUSDT:22542182"), act.TakeProfit.ID)
+		assert.Equal(t, act.Enter.ID, act.TakeProfit.ParentID)
+
+		assert.Len(t, *requests, 3)
+		stop := (*requests)[1].Params
+		assert.Equal(t, "STOP_MARKET", stop.Get("type"))
+		assert.Equal(t, "SELL", stop.Get("side"))
+		assert.Equal(t, "18000", stop.Get("stopPrice"))
+		assert.Equal(t, "0.01", stop.Get("quantity"))
+		assert.Equal(t, "true", stop.Get("reduceOnly"))
+		takeProfit := (*requests)[2].Params
+		assert.Equal(t, "TAKE_PROFIT_MARKET", takeProfit.Get("type"))
+		assert.Equal(t, "20000", takeProfit.Get("stopPrice"))
+		assert.Equal(t, "true", takeProfit.Get("reduceOnly"))
+		assert.Empty(t, takeProfit.Get("price"))
+	})
+
+	t.Run("legs listed as placed", func(t *testing.T) {
+		dealer, _ := newDealerForTest(t, map[string]string{
+			"POST /fapi/v1/order":                         "order_new.json",
+			"POST /fapi/v1/order?type=STOP_MARKET":        "order_stop_new.json",
+			"POST /fapi/v1/order?type=TAKE_PROFIT_MARKET": "order_take_profit_new.json",
+			"GET /fapi/v1/openOrders":                     "orders_bracket_open.json",
+		})
+		placed, _, err := dealer.PlaceBracketOrder(context.Background(), give)
+		assert.NoError(t, err)
+		listed, _, err := dealer.ListOrders(context.Background(), &broker.OrderFilter{States: []broker.OrderState{broker.OrderOpen}}, nil)
+		assert.NoError(t, err)
+		assert.Len(t, listed, 3)
+
+		for i, want := range []broker.Order{placed.Enter, placed.Stop, placed.TakeProfit} {
+			assert.Equal(t, want.ID, listed[i].ID)
+			assert.Equal(t, want.Type, listed[i].Type)
+			assert.Equal(t, want.Side, listed[i].Side)
+			assert.Equal(t, want.ReduceOnly, listed[i].ReduceOnly)
+			assert.True(t, want.LimitPrice.Equal(listed[i].LimitPrice), listed[i].LimitPrice.String())
+			assert.True(t, want.StopPrice.Equal(listed[i].StopPrice), listed[i].StopPrice.String())
+		}
+		assert.Equal(t, broker.Limit, listed[2].Type)
+		assert.True(t, listed[2].LimitPrice.Equal(give.TakeProfit.LimitPrice))
+		assert.True(t, listed[2].ReduceOnly)
+	})
+
+	t.Run("placed orders cancelled when a leg fails", func(t *testing.T) {
+		dealer, requests := newDealerForTest(t, map[string]string{
+			"POST /fapi/v1/order":                         "order_new.json",
+			"POST /fapi/v1/order?type=STOP_MARKET":        "order_stop_new.json",
+			"POST /fapi/v1/order?type=TAKE_PROFIT_MARKET": "error_order_would_trigger.json",
+			"DELETE /fapi/v1/order":                       "order_new.json",
+		})
+		act, _, err := dealer.PlaceBracketOrder(context.Background(), give)
+		var apiErr *APIError
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, -2021, apiErr.Code)
+		assert.Nil(t, act)
+
+		assert.Len(t, *requests, 5)
+		assert.Equal(t, http.MethodDelete, (*requests)[3].Method)
+		assert.Equal(t, "22542181", (*requests)[3].Params.Get("orderId"))
+		assert.Equal(t, http.MethodDelete, (*requests)[4].Method)
+		assert.Equal(t, "22542179", (*requests)[4].Params.Get("orderId"))
+	})
+
+	t.Run("leg on the enter side", func(t *testing.T) {
+		dealer, requests := newDealerForTest(t, nil)
+		invalid := give
+		invalid.Stop.Side = broker.Buy
+		_, _, err := dealer.PlaceBracketOrder(context.Background(), invalid)
+		assert.ErrorIs(t, err, ErrInvalidBracket)
+		assert.Empty(t, *requests)
+	})
+}
+
+func TestDealer_GetOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		giveID  broker.DealID
+		giveFix string
+		want    broker.Order
+		wantErr error
+	}{
+		{
+			name:    "filled stop order",
+			giveID:  "BTCUSDT:22542180",
+			giveFix: "order_filled.json",
+			want: broker.Order{
+				ID:          "BTCUSDT:22542180",
+				FilledAt:    time.UnixMilli(1656637200000),
+				Side:        broker.Sell,
+				Type:        broker.Stop,
+				StopPrice:   dec.New(18550),
+				ReduceOnly:  true,
+				FilledPrice: dec.New(18500.5),
+				FilledSize:  dec.New(0.01),
+			},
+		},
+		{
+			name:    "unknown order",
+			giveID:  "BTCUSDT:1",
+			giveFix: "error_unknown_order.json",
+			wantErr: broker.ErrNotFound,
+		},
+		{
+			name:    "invalid id",
+			giveID:  "1",
+			wantErr: broker.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dealer, _ := newDealerForTest(t, map[string]string{"GET /fapi/v1/order": tt.giveFix})
+			act, _, err := dealer.GetOrder(context.Background(), tt.giveID)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr != nil {
+				return
+			}
+			assert.Equal(t, tt.want.ID, act.ID)
+			assert.True(t, act.State() == broker.OrderClosed)
+			assert.True(t, tt.want.FilledAt.Equal(act.FilledAt))
+			assert.Equal(t, tt.want.Type, act.Type)
+			assert.Equal(t, tt.want.Side, act.Side)
+			assert.True(t, tt.want.StopPrice.Equal(act.StopPrice))
+			assert.True(t, tt.want.FilledPrice.Equal(act.FilledPrice))
+			assert.True(t, tt.want.FilledSize.Equal(act.FilledSize))
+			assert.Equal(t, tt.want.ReduceOnly, act.ReduceOnly)
+		})
+	}
+}
+
+func TestDealer_AmendOrder(t *testing.T) {
+	dealer, requests := newDealerForTest(t, map[string]string{
+		"GET /fapi/v1/order": "order_new.json",
+		"PUT /fapi/v1/order": "order_new.json",
+	})
+
+	_, _, err := dealer.AmendOrder(context.Background(), "BTCUSDT:22542179", broker.AmendOpts{LimitPrice: dec.New(18900)})
+	assert.NoError(t, err)
+	assert.Len(t, *requests, 2)
+	params := (*requests)[1].Params
+	assert.Equal(t, http.MethodPut, (*requests)[1].Method)
+	assert.Equal(t, "22542179", params.Get("orderId"))
+	assert.Equal(t, "BUY", params.Get("side"))
+	assert.Equal(t, "18900", params.Get("price"))
+	assert.Equal(t, "0.01", params.Get("quantity"))
+
+	_, _, err = dealer.AmendOrder(context.Background(), "BTCUSDT:22542179", broker.AmendOpts{StopPrice: dec.New(18000)})
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
+
+func TestDealer_CancelOrders(t *testing.T) {
+	failing, _ := newDealerForTest(t, map[string]string{
+		"GET /fapi/v1/openOrders":       "orders_open.json",
+		"DELETE /fapi/v1/allOpenOrders": "error_unknown_order.json",
+	})
+	_, err := failing.CancelOrders(context.Background())
+	assert.ErrorIs(t, err, broker.ErrNotFound)
+
+	dealer, requests := newDealerForTest(t, map[string]string{
+		"GET /fapi/v1/openOrders":       "orders_open.json",
+		"DELETE /fapi/v1/allOpenOrders": "order_new.json",
+	})
+	_, err = dealer.CancelOrders(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, *requests, 3)
+	assert.Equal(t, "BTCUSDT", (*requests)[1].Params.Get("symbol"))
+	assert.Equal(t, "ETHUSDT", (*requests)[2].Params.Get("symbol"))
+}
+
+func TestDealer_ListOrders(t *testing.T) {
+	dealer, requests := newDealerForTest(t, map[string]string{
+		"GET /fapi/v1/openOrders": "orders_open.json",
+		"GET /fapi/v1/allOrders":  "orders_open.json",
+	})
+	openFilter := &broker.OrderFilter{States: []broker.OrderState{broker.OrderOpen}}
+
+	act, res, err := dealer.ListOrders(context.Background(), openFilter, &web.ListOpts{PageSize: 2, PageNum: 2})
+	assert.NoError(t, err)
+	assert.Len(t, act, 1)
+	assert.Equal(t, broker.TrailingStop, act[0].Type)
+	assert.True(t, act[0].TrailPct.Equal(dec.New(0.015)))
+	assert.Equal(t, web.Page{PageSize: 2, PageNum: 2, PagesTotal: 2}, res.Meta.Page)
+
+	act, _, err = dealer.ListOrders(context.Background(), &broker.OrderFilter{Asset: market.NewAsset("BTCUSDT"), Side: broker.Buy, To: _fixedDate}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, act, 1)
+	assert.Equal(t, "/fapi/v1/allOrders", (*requests)[1].Path)
+	assert.Equal(t, "1656678614999", (*requests)[1].Params.Get("endTime"))
+
+	_, _, err = dealer.ListOrders(context.Background(), nil, nil)
+	assert.ErrorIs(t, err, ErrAssetRequired)
+}
+
+func TestDealer_ListPositions(t *testing.T) {
+	dealer, _ := newDealerForTest(t, map[string]string{"GET /fapi/v2/positionRisk": "position_risk.json"})
+
+	act, _, err := dealer.ListPositions(context.Background(), nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, act, 2)
+	assert.Equal(t, broker.DealID("BTCUSDT:BOTH"), act[0].ID)
+	assert.True(t, act[0].State() == broker.PositionOpen)
+	assert.Equal(t, broker.Buy, act[0].Side)
+	assert.Equal(t, broker.Sell, act[1].Side)
+	assert.True(t, act[1].Size.Equal(dec.New(2)))
+	assert.True(t, act[1].Cost.Equal(dec.New(2200)))
+	assert.True(t, act[1].UnrealizedPNL.Equal(dec.New(100)))
+
+	act, _, err = dealer.ListPositions(context.Background(), &broker.PositionFilter{Side: broker.Sell}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, act, 1)
+	assert.Equal(t, market.NewAsset("ETHUSDT"), act[0].Asset)
+}
+
+func TestDealer_ListFills(t *testing.T) {
+	dealer, requests := newDealerForTest(t, map[string]string{"GET /fapi/v1/userTrades": "user_trades.json"})
+
+	filter := &broker.FillFilter{Asset: market.NewAsset("BTCUSDT"), OrderID: "BTCUSDT:25851814"}
+	act, _, err := dealer.ListFills(context.Background(), filter, nil)
+	assert.NoError(t, err)
+	assert.Len(t, act, 1)
+	assert.Equal(t, "25851814", (*requests)[0].Params.Get("orderId"))
+	assert.Equal(t, broker.Maker, act[0].Liquidity)
+	assert.Equal(t, broker.Sell, act[0].Side)
+	assert.True(t, act[0].RealizedPNL.Equal(dec.New(10)))
+	assert.True(t, act[0].Fee.Equal(dec.New(0.039)))
+
+	_, _, err = dealer.ListFills(context.Background(), nil, nil)
+	assert.ErrorIs(t, err, ErrAssetRequired)
+}
+
+func TestSign(t *testing.T) {
+	// Example from the API documentation
+	secret := "NhqPtmdSJYdKjVHjA7PZj4Mge3R5YNiP1e3UZjInClVN65XAbvqqM6A7H5fATj0j"
+	query := "symbol=LTCBTC&side=BUY&type=LIMIT&timeInForce=GTC&quantity=1&price=0.1&recvWindow=5000&timestamp=1499827319559"
+	want := "c8db56825ae71d6d79447849e617115f4a920fa2acdcab2b053c4b2838bd6b71"
+	assert.Equal(t, want, sign(secret, query))
+}
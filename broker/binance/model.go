@@ -0,0 +1,350 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package binance
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+// apiOrder is an order as returned by the API.
+type apiOrder struct {
+	OrderID      int64           `json:"orderId"`
+	Symbol       string          `json:"symbol"`
+	Status       string          `json:"status"`
+	Side         string          `json:"side"`
+	PositionSide string          `json:"positionSide"`
+	Type         string          `json:"type"`
+	OrigType     string          `json:"origType"`
+	TimeInForce  string          `json:"timeInForce"`
+	Price        decimal.Decimal `json:"price"`
+	StopPrice    decimal.Decimal `json:"stopPrice"`
+	AvgPrice     decimal.Decimal `json:"avgPrice"`
+	OrigQty      decimal.Decimal `json:"origQty"`
+	ExecutedQty  decimal.Decimal `json:"executedQty"`
+	PriceRate    string          `json:"priceRate"`
+	ReduceOnly   bool            `json:"reduceOnly"`
+	GoodTillDate int64           `json:"goodTillDate"`
+	Time         int64           `json:"time"`
+	UpdateTime   int64           `json:"updateTime"`
+}
+
+// apiPosition is a position as returned by the position risk endpoint.
+type apiPosition struct {
+	Symbol           string          `json:"symbol"`
+	PositionSide     string          `json:"positionSide"`
+	PositionAmt      decimal.Decimal `json:"positionAmt"`
+	EntryPrice       decimal.Decimal `json:"entryPrice"`
+	MarkPrice        decimal.Decimal `json:"markPrice"`
+	UnRealizedProfit decimal.Decimal `json:"unRealizedProfit"`
+	UpdateTime       int64           `json:"updateTime"`
+}
+
+// apiTrade is an account trade (fill) as returned by the API.
+type apiTrade struct {
+	ID           int64           `json:"id"`
+	OrderID      int64           `json:"orderId"`
+	Symbol       string          `json:"symbol"`
+	Side         string          `json:"side"`
+	PositionSide string          `json:"positionSide"`
+	Price        decimal.Decimal `json:"price"`
+	Qty          decimal.Decimal `json:"qty"`
+	Commission   decimal.Decimal `json:"commission"`
+	RealizedPnl  decimal.Decimal `json:"realizedPnl"`
+	Maker        bool            `json:"maker"`
+	Time         int64           `json:"time"`
+}
+
+// apiAccount is the futures account information as returned by the API.
+type apiAccount struct {
+	TotalWalletBalance decimal.Decimal `json:"totalWalletBalance"`
+	TotalMarginBalance decimal.Decimal `json:"totalMarginBalance"`
+	TotalInitialMargin decimal.Decimal `json:"totalInitialMargin"`
+	AvailableBalance   decimal.Decimal `json:"availableBalance"`
+	Assets             []struct {
+		Asset         string          `json:"asset"`
+		WalletBalance decimal.Decimal `json:"walletBalance"`
+		MarginBalance decimal.Decimal `json:"marginBalance"`
+	} `json:"assets"`
+}
+
+var _orderTypes = map[broker.OrderType]string{
+	broker.Market:       "MARKET",
+	broker.Limit:        "LIMIT",
+	broker.Stop:         "STOP_MARKET",
+	broker.StopLimit:    "STOP",
+	broker.TrailingStop: "TRAILING_STOP_MARKET",
+}
+
+var _timeInForces = map[broker.TimeInForce]string{
+	broker.GTC:      "GTC",
+	broker.IOC:      "IOC",
+	broker.FOK:      "FOK",
+	broker.GTD:      "GTD",
+	broker.PostOnly: "GTX",
+}
+
+// newOrderID returns the DealID of an order.
+// The API identifies orders by symbol and order ID, so both are encoded in the DealID.
+func newOrderID(symbol string, id int64) broker.DealID {
+	return broker.DealID(symbol + ":" + strconv.FormatInt(id, 10))
+}
+
+// parseOrderID returns the symbol and order ID encoded in a DealID by newOrderID.
+func parseOrderID(id broker.DealID) (string, string, error) {
+	symbol, orderID, ok := strings.Cut(string(id), ":")
+	if !ok || symbol == "" || orderID == "" {
+		return "", "", fmt.Errorf("invalid order id %q: %w", id, broker.ErrNotFound)
+	}
+	return symbol, orderID, nil
+}
+
+// newPositionID returns the DealID of the position of a symbol and position side.
+func newPositionID(symbol, positionSide string) broker.DealID {
+	return broker.DealID(symbol + ":" + positionSide)
+}
+
+// orderParams returns the request params to place an order.
+func orderParams(o broker.Order) (url.Values, error) {
+	orderType, ok := _orderTypes[o.Type]
+	if !ok {
+		return nil, fmt.Errorf("order type %s: %w", o.Type, ErrNotSupported)
+	}
+
+	params := url.Values{}
+	params.Set("symbol", o.Asset.Symbol)
+	params.Set("side", strings.ToUpper(o.Side.String()))
+	params.Set("type", orderType)
+	params.Set("quantity", o.Size.String())
+	if o.ID != "" {
+		params.Set("newClientOrderId", string(o.ID))
+	}
+	if o.PositionSide != 0 {
+		params.Set("positionSide", positionSide(o.PositionSide))
+	} else if o.ReduceOnly {
+		// Reduce only is rejected in hedge mode, where the position side implies it
+		params.Set("reduceOnly", "true")
+	}
+
+	switch o.Type {
+	case broker.Limit, broker.StopLimit:
+		params.Set("price", o.LimitPrice.String())
+		tif := o.TimeInForce
+		if tif == 0 {
+			tif = broker.GTC
+		}
+		params.Set("timeInForce", _timeInForces[tif])
+		if tif == broker.GTD {
+			params.Set("goodTillDate", strconv.FormatInt(o.ExpiresAt.UnixMilli(), 10))
+		}
+	}
+	switch o.Type {
+	case broker.Stop, broker.StopLimit:
+		params.Set("stopPrice", o.StopPrice.String())
+	case broker.TrailingStop:
+		if !o.TrailPct.IsPositive() {
+			return nil, fmt.Errorf("trailing stop without trail pct: %w", ErrNotSupported)
+		}
+		// The callback rate is a percentage
+		params.Set("callbackRate", o.TrailPct.Mul(dec.New(100)).String())
+		if o.StopPrice.IsPositive() {
+			params.Set("activationPrice", o.StopPrice.String())
+		}
+	}
+	return params, nil
+}
+
+// bracketLegParams returns the request params to place a leg of a bracket as a reduce only trigger order
+// of the given type, that exits the position opened by the enter order.
+func bracketLegParams(enter, leg broker.Order, orderType string) (url.Values, error) {
+	if leg.Side != enter.Side.Opposite() {
+		return nil, ErrInvalidBracket
+	}
+	if leg.Type == broker.Limit {
+		leg.StopPrice = leg.LimitPrice
+	}
+	leg.Asset = enter.Asset
+	leg.Type = broker.Stop
+	leg.ReduceOnly = true
+	if leg.Size.IsZero() {
+		leg.Size = enter.Size
+	}
+	if leg.PositionSide == 0 {
+		leg.PositionSide = enter.PositionSide
+	}
+
+	params, err := orderParams(leg)
+	if err != nil {
+		return nil, err
+	}
+	params.Set("type", orderType)
+	return params, nil
+}
+
+// toOrder converts an API order to a broker.Order.
+// Order types without an equivalent, such as TAKE_PROFIT, have a zero Type.
+func toOrder(o apiOrder) broker.Order {
+	out := broker.Order{
+		ID:           newOrderID(o.Symbol, o.OrderID),
+		Asset:        market.NewAsset(o.Symbol),
+		Side:         orderSide(o.Side),
+		PositionSide: orderSide(o.PositionSide),
+		StopPrice:    o.StopPrice,
+		Size:         o.OrigQty,
+		ReduceOnly:   o.ReduceOnly,
+		FilledSize:   o.ExecutedQty,
+	}
+	if o.ExecutedQty.IsPositive() {
+		out.FilledPrice = o.AvgPrice
+	}
+
+	orderType := o.OrigType
+	if orderType == "" {
+		orderType = o.Type
+	}
+	for k, v := range _orderTypes {
+		if v == orderType {
+			out.Type = k
+		}
+	}
+	if out.Type == broker.Limit || out.Type == broker.StopLimit {
+		out.LimitPrice = o.Price
+	}
+	// Take profit orders are placed for the Limit legs of a bracket, so are returned as reduce-only Limit orders
+	// at the trigger price
+	if orderType == "TAKE_PROFIT_MARKET" || orderType == "TAKE_PROFIT" {
+		out.Type = broker.Limit
+		out.LimitPrice = o.StopPrice
+		out.StopPrice = decimal.Zero
+	}
+	if out.Type == broker.TrailingStop {
+		if rate, err := decimal.NewFromString(o.PriceRate); err == nil {
+			out.TrailPct = rate.Div(dec.New(100))
+		}
+	}
+	for k, v := range _timeInForces {
+		if v == o.TimeInForce {
+			out.TimeInForce = k
+		}
+	}
+	if out.TimeInForce == broker.GTD && o.GoodTillDate > 0 {
+		out.ExpiresAt = time.UnixMilli(o.GoodTillDate)
+	}
+
+	// The creation time is not returned when an order is placed, only the update time
+	openedAt := o.Time
+	if openedAt == 0 {
+		openedAt = o.UpdateTime
+	}
+	out.OpenedAt = time.UnixMilli(openedAt)
+	updatedAt := time.UnixMilli(o.UpdateTime)
+	switch o.Status {
+	case "FILLED", "NEW_INSURANCE", "NEW_ADL":
+		out.FilledAt = updatedAt
+		out.ClosedAt = updatedAt
+	case "CANCELED", "EXPIRED", "REJECTED":
+		out.ClosedAt = updatedAt
+	}
+	return out
+}
+
+// toPosition converts an API position to a broker.Position.
+// Only open positions are returned by the API and the time the position was opened is not known,
+// so OpenedAt is the time the position was last updated.
+func toPosition(p apiPosition) broker.Position {
+	out := broker.Position{
+		ID:            newPositionID(p.Symbol, p.PositionSide),
+		OpenedAt:      time.UnixMilli(p.UpdateTime),
+		Asset:         market.NewAsset(p.Symbol),
+		Side:          broker.Buy,
+		Size:          p.PositionAmt.Abs(),
+		EntryPrice:    p.EntryPrice,
+		MarkPrice:     p.MarkPrice,
+		PNL:           p.UnRealizedProfit,
+		UnrealizedPNL: p.UnRealizedProfit,
+	}
+	if p.PositionAmt.IsNegative() {
+		out.Side = broker.Sell
+	}
+	out.Cost = out.Size.Mul(out.EntryPrice)
+	return out
+}
+
+// toFill converts an API trade to a broker.Fill.
+func toFill(t apiTrade) broker.Fill {
+	out := broker.Fill{
+		ID:          broker.DealID(t.Symbol + ":" + strconv.FormatInt(t.ID, 10)),
+		OrderID:     newOrderID(t.Symbol, t.OrderID),
+		PositionID:  newPositionID(t.Symbol, t.PositionSide),
+		FilledAt:    time.UnixMilli(t.Time),
+		Asset:       market.NewAsset(t.Symbol),
+		Side:        orderSide(t.Side),
+		Price:       t.Price,
+		Size:        t.Qty,
+		Fee:         t.Commission,
+		Liquidity:   broker.Taker,
+		RealizedPNL: t.RealizedPnl,
+	}
+	if t.Maker {
+		out.Liquidity = broker.Maker
+	}
+	return out
+}
+
+// toBalance converts the API account information to a broker.AccountBalance.
+// The balance of each asset is listed only when the account holds more than one asset (multi-assets mode).
+// The account does not report the currency of the totals, so Currency is left empty.
+func toBalance(a apiAccount) broker.AccountBalance {
+	out := broker.AccountBalance{
+		Trade:           a.TotalWalletBalance,
+		Equity:          a.TotalMarginBalance,
+		MarginUsed:      a.TotalInitialMargin,
+		AvailableMargin: a.AvailableBalance,
+	}
+	var currencies []broker.CurrencyBalance
+	for _, asset := range a.Assets {
+		if asset.WalletBalance.IsZero() && asset.MarginBalance.IsZero() {
+			continue
+		}
+		currencies = append(currencies, broker.CurrencyBalance{
+			Currency: asset.Asset,
+			Trade:    asset.WalletBalance,
+			Equity:   asset.MarginBalance,
+		})
+	}
+	if len(currencies) > 1 {
+		out.Currencies = currencies
+	}
+	return out
+}
+
+// orderSide converts an order side (BUY, SELL) or position side (LONG, SHORT, BOTH) to an OrderSide.
+func orderSide(side string) broker.OrderSide {
+	switch side {
+	case "BUY", "LONG":
+		return broker.Buy
+	case "SELL", "SHORT":
+		return broker.Sell
+	}
+	return 0
+}
+
+// positionSide converts an OrderSide to a position side.
+func positionSide(side broker.OrderSide) string {
+	switch side {
+	case broker.Buy:
+		return "LONG"
+	case broker.Sell:
+		return "SHORT"
+	}
+	return "BOTH"
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package binance
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestOrderParams(t *testing.T) {
+	btc := market.NewAsset("BTCUSDT")
+	expires := time.UnixMilli(1656633600000)
+
+	tests := []struct {
+		name    string
+		give    broker.Order
+		want    url.Values
+		wantErr error
+	}{
+		{
+			name: "market reduce only",
+			give: broker.Order{Asset: btc, Side: broker.Sell, Type: broker.Market, Size: dec.New(1), ReduceOnly: true},
+			want: url.Values{
+				"symbol": {"BTCUSDT"}, "side": {"SELL"}, "type": {"MARKET"}, "quantity": {"1"}, "reduceOnly": {"true"},
+			},
+		},
+		{
+			name: "post only limit in hedge mode",
+			give: broker.Order{Asset: btc, Side: broker.Sell, Type: broker.Limit, LimitPrice: dec.New(100), Size: dec.New(1),
+				PositionSide: broker.Buy, ReduceOnly: true, TimeInForce: broker.PostOnly},
+			want: url.Values{
+				"symbol": {"BTCUSDT"}, "side": {"SELL"}, "type": {"LIMIT"}, "quantity": {"1"}, "positionSide": {"LONG"},
+				"price": {"100"}, "timeInForce": {"GTX"},
+			},
+		},
+		{
+			name: "good till date stop limit",
+			give: broker.Order{Asset: btc, Side: broker.Buy, Type: broker.StopLimit, LimitPrice: dec.New(101), StopPrice: dec.New(100),
+				Size: dec.New(1), TimeInForce: broker.GTD, ExpiresAt: expires},
+			want: url.Values{
+				"symbol": {"BTCUSDT"}, "side": {"BUY"}, "type": {"STOP"}, "quantity": {"1"}, "price": {"101"}, "stopPrice": {"100"},
+				"timeInForce": {"GTD"}, "goodTillDate": {"1656633600000"},
+			},
+		},
+		{
+			name: "trailing stop",
+			give: broker.Order{Asset: btc, Side: broker.Sell, Type: broker.TrailingStop, TrailPct: dec.New(0.02), Size: dec.New(1)},
+			want: url.Values{
+				"symbol": {"BTCUSDT"}, "side": {"SELL"}, "type": {"TRAILING_STOP_MARKET"}, "quantity": {"1"}, "callbackRate": {"2"},
+			},
+		},
+		{
+			name:    "trailing stop by amount",
+			give:    broker.Order{Asset: btc, Side: broker.Sell, Type: broker.TrailingStop, TrailAmount: dec.New(10), Size: dec.New(1)},
+			wantErr: ErrNotSupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := orderParams(tt.give)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, act)
+		})
+	}
+}
+
+func TestToOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		giveStatus string
+		want       broker.OrderState
+	}{
+		{name: "new", giveStatus: "NEW", want: broker.OrderOpen},
+		{name: "partially filled", giveStatus: "PARTIALLY_FILLED", want: broker.OrderOpen},
+		{name: "filled", giveStatus: "FILLED", want: broker.OrderClosed},
+		{name: "cancelled", giveStatus: "CANCELED", want: broker.OrderClosed},
+		{name: "expired", giveStatus: "EXPIRED", want: broker.OrderClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := toOrder(apiOrder{Symbol: "BTCUSDT", Status: tt.giveStatus, Time: 1656633600000, UpdateTime: 1656637200000})
+			assert.True(t, act.State() == tt.want)
+			if tt.giveStatus == "FILLED" {
+				assert.False(t, act.FilledAt.IsZero())
+			}
+		})
+	}
+}
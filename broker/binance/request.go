@@ -0,0 +1,119 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package binance
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/web"
+)
+
+const (
+	_headerAPIKey     = "X-MBX-APIKEY"
+	_headerUsedWeight = "X-MBX-USED-WEIGHT-1M"
+)
+
+// Error codes returned by the API for an order that does not exist.
+const (
+	_codeUnknownOrder    = -2011
+	_codeOrderNotExisted = -2013
+)
+
+// APIError is an error response returned by the API.
+type APIError struct {
+	StatusCode int
+	Code       int    `json:"code"`
+	Msg        string `json:"msg"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("binance: %d %s (status %d)", e.Code, e.Msg, e.StatusCode)
+}
+
+// Unwrap returns broker.ErrNotFound for an unknown order, so that errors.Is can be used to test for it.
+func (e *APIError) Unwrap() error {
+	if e.Code == _codeUnknownOrder || e.Code == _codeOrderNotExisted {
+		return broker.ErrNotFound
+	}
+	return nil
+}
+
+// do sends a signed request with the given params and decodes the JSON response body into out.
+// The returned response is populated with the rate limit status even if the request fails.
+func (d *Dealer) do(ctx context.Context, method, path string, params url.Values, out any) (*web.Response, error) {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("recvWindow", strconv.FormatInt(d.recvWindow.Milliseconds(), 10))
+	params.Set("timestamp", strconv.FormatInt(d.now().UnixMilli(), 10))
+	query := params.Encode()
+	query += "&signature=" + sign(d.secret, query)
+
+	req, err := http.NewRequestWithContext(ctx, method, d.baseURL+path+"?"+query, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(_headerAPIKey, d.apiKey)
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	res := &web.Response{Resp: resp, Meta: web.ResponseMetadata{Rate: d.parseRate(resp)}}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return res, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := APIError{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(body, &apiErr)
+		return res, &apiErr
+	}
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// sign returns the hex encoded HMAC SHA256 signature of the query string.
+func sign(secret, query string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(query))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseRate returns the request weight rate limit status from the response headers.
+// The weight limit resets at the start of each minute, timed from the response Date header when present.
+func (d *Dealer) parseRate(resp *http.Response) web.Rate {
+	rate := web.Rate{Limit: d.weightLimit, Remaining: d.weightLimit}
+	if used, err := strconv.Atoi(resp.Header.Get(_headerUsedWeight)); err == nil {
+		rate.Remaining = d.weightLimit - used
+		if rate.Remaining < 0 {
+			rate.Remaining = 0
+		}
+	}
+
+	now := d.now()
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		now = date
+	}
+	rate.ResetAt = now.Truncate(time.Minute).Add(time.Minute)
+	return rate
+}
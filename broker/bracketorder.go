@@ -4,7 +4,11 @@
 package broker
 
 // BracketOrder groups together a set of dependent orders to open and manage a new position.
+// Stop and TakeProfit are optional legs that exit the position and act as one-cancels-other:
+// when one leg is filled the other is cancelled. Legs are cancelled if the Enter order is cancelled.
+// An unset leg has a zero Type.
 type BracketOrder struct {
-	Enter Order
-	Stop  Order
+	Enter      Order
+	Stop       Order
+	TakeProfit Order
 }
@@ -2,24 +2,34 @@
 // SPDX-License-Identifier: MIT
 
 // Package broker provides an API for interacting with 3rd party exchanges,
-// and a simulated dealer for backtesting in the child package backtest.
+// with a simulated dealer for backtesting in the child package backtest and a dealer for Binance USD-M futures in binance.
 package broker
 
 import (
 	"context"
+	"errors"
 
 	"github.com/shopspring/decimal"
 	"github.com/thecolngroup/alphakit/market"
 	"github.com/thecolngroup/alphakit/web"
 )
 
+// ErrNotFound is returned when a requested dealer entity does not exist.
+var ErrNotFound = errors.New("entity not found")
+
 // Dealer is an interface for interacting with a 3rd party exchange and placing orders in the market.
 type Dealer interface {
 	GetBalance(context.Context) (*AccountBalance, *web.Response, error)
 	PlaceOrder(context.Context, Order) (*Order, *web.Response, error)
+	PlaceBracketOrder(context.Context, BracketOrder) (*BracketOrder, *web.Response, error)
 	CancelOrders(context.Context) (*web.Response, error)
-	ListPositions(context.Context, *web.ListOpts) ([]Position, *web.Response, error)
-	ListRoundTurns(context.Context, *web.ListOpts) ([]RoundTurn, *web.Response, error)
+	CancelOrder(context.Context, DealID) (*Order, *web.Response, error)
+	AmendOrder(context.Context, DealID, AmendOpts) (*Order, *web.Response, error)
+	GetOrder(context.Context, DealID) (*Order, *web.Response, error)
+	ListOrders(context.Context, *OrderFilter, *web.ListOpts) ([]Order, *web.Response, error)
+	ListPositions(context.Context, *PositionFilter, *web.ListOpts) ([]Position, *web.Response, error)
+	ListRoundTurns(context.Context, *RoundTurnFilter, *web.ListOpts) ([]RoundTurn, *web.Response, error)
+	ListFills(context.Context, *FillFilter, *web.ListOpts) ([]Fill, *web.Response, error)
 }
 
 // SimulatedDealer is a Dealer that can be used for backtesting.
@@ -0,0 +1,108 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package broker
+
+import (
+	"time"
+
+	"github.com/thecolngroup/alphakit/market"
+	"golang.org/x/exp/slices"
+)
+
+// PositionFilter is used to filter the positions returned by a Dealer.
+// Zero value fields are not applied.
+type PositionFilter struct {
+	Asset  market.Asset
+	Side   OrderSide
+	States []PositionState
+
+	// From and To select positions opened in the time range [From, To)
+	From time.Time
+	To   time.Time
+}
+
+// Match returns true if the position satisfies the filter.
+// A nil filter matches all positions.
+func (f *PositionFilter) Match(position Position) bool {
+	if f == nil {
+		return true
+	}
+	if f.Asset != (market.Asset{}) && !position.Asset.Equal(f.Asset) {
+		return false
+	}
+	if f.Side != 0 && position.Side != f.Side {
+		return false
+	}
+	if len(f.States) > 0 && !slices.Contains(f.States, position.State()) {
+		return false
+	}
+	return inTimeRange(position.OpenedAt, f.From, f.To)
+}
+
+// RoundTurnFilter is used to filter the round-turns returned by a Dealer.
+// Zero value fields are not applied.
+type RoundTurnFilter struct {
+	Asset market.Asset
+	Side  OrderSide
+
+	// From and To select round-turns created in the time range [From, To)
+	From time.Time
+	To   time.Time
+}
+
+// Match returns true if the round-turn satisfies the filter.
+// A nil filter matches all round-turns.
+func (f *RoundTurnFilter) Match(roundturn RoundTurn) bool {
+	if f == nil {
+		return true
+	}
+	if f.Asset != (market.Asset{}) && !roundturn.Asset.Equal(f.Asset) {
+		return false
+	}
+	if f.Side != 0 && roundturn.Side != f.Side {
+		return false
+	}
+	return inTimeRange(roundturn.CreatedAt, f.From, f.To)
+}
+
+// FillFilter is used to filter the fills returned by a Dealer.
+// Zero value fields are not applied.
+type FillFilter struct {
+	Asset   market.Asset
+	Side    OrderSide
+	OrderID DealID
+
+	// From and To select fills filled in the time range [From, To)
+	From time.Time
+	To   time.Time
+}
+
+// Match returns true if the fill satisfies the filter.
+// A nil filter matches all fills.
+func (f *FillFilter) Match(fill Fill) bool {
+	if f == nil {
+		return true
+	}
+	if f.Asset != (market.Asset{}) && !fill.Asset.Equal(f.Asset) {
+		return false
+	}
+	if f.Side != 0 && fill.Side != f.Side {
+		return false
+	}
+	if f.OrderID != "" && fill.OrderID != f.OrderID {
+		return false
+	}
+	return inTimeRange(fill.FilledAt, f.From, f.To)
+}
+
+// inTimeRange returns true if t is in the range [from, to), a zero from or to is unbounded.
+func inTimeRange(t, from, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+	if !to.IsZero() && !t.Before(to) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+func TestPositionFilter_Match(t *testing.T) {
+	btc := market.NewAsset("BTCUSD")
+	fixed := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	open := Position{Asset: btc, Side: Sell, OpenedAt: fixed}
+
+	tests := []struct {
+		name         string
+		giveFilter   *PositionFilter
+		givePosition Position
+		want         bool
+	}{
+		{
+			name:         "nil filter",
+			giveFilter:   nil,
+			givePosition: open,
+			want:         true,
+		},
+		{
+			name:         "match asset side and state",
+			giveFilter:   &PositionFilter{Asset: btc, Side: Sell, States: []PositionState{PositionOpen}},
+			givePosition: open,
+			want:         true,
+		},
+		{
+			name:         "no match asset",
+			giveFilter:   &PositionFilter{Asset: market.NewAsset("ETHUSD")},
+			givePosition: open,
+			want:         false,
+		},
+		{
+			name:         "no match side",
+			giveFilter:   &PositionFilter{Side: Buy},
+			givePosition: open,
+			want:         false,
+		},
+		{
+			name:         "no match state",
+			giveFilter:   &PositionFilter{States: []PositionState{PositionClosed}},
+			givePosition: open,
+			want:         false,
+		},
+		{
+			name:         "match open ended time range",
+			giveFilter:   &PositionFilter{From: fixed},
+			givePosition: open,
+			want:         true,
+		},
+		{
+			name:         "no match time range",
+			giveFilter:   &PositionFilter{To: fixed},
+			givePosition: open,
+			want:         false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.giveFilter.Match(tt.givePosition))
+		})
+	}
+}
+
+func TestFillFilter_Match(t *testing.T) {
+	btc := market.NewAsset("BTCUSD")
+	fixed := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	fill := Fill{OrderID: "1", Asset: btc, Side: Buy, FilledAt: fixed}
+
+	tests := []struct {
+		name       string
+		giveFilter *FillFilter
+		want       bool
+	}{
+		{
+			name:       "nil filter",
+			giveFilter: nil,
+			want:       true,
+		},
+		{
+			name:       "match all fields",
+			giveFilter: &FillFilter{Asset: btc, Side: Buy, OrderID: "1", From: fixed, To: fixed.Add(time.Second)},
+			want:       true,
+		},
+		{
+			name:       "no match order",
+			giveFilter: &FillFilter{OrderID: "2"},
+			want:       false,
+		},
+		{
+			name:       "no match time range",
+			giveFilter: &FillFilter{From: fixed.Add(time.Second)},
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.giveFilter.Match(fill))
+		})
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package broker
+
+import (
+	"errors"
+)
+
+// ErrUnknownEnumText is returned when text cannot be parsed to a known enum value.
+var ErrUnknownEnumText = errors.New("unknown enum text")
+
+// parseEnum returns the index of the text in the enum names.
+func parseEnum(text []byte, names []string) (int, error) {
+	for i := range names {
+		if names[i] == string(text) {
+			return i, nil
+		}
+	}
+	return 0, ErrUnknownEnumText
+}
@@ -0,0 +1,60 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package broker
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+// Liquidity represents whether a fill added liquidity to the market (maker) or removed it (taker).
+type Liquidity int
+
+const (
+	// Maker fills add liquidity, typically resting limit orders.
+	Maker Liquidity = iota + 1
+
+	// Taker fills remove liquidity, typically market and stop orders.
+	Taker
+)
+
+var _liquidityNames = []string{"None", "Maker", "Taker"}
+
+func (l Liquidity) String() string {
+	return _liquidityNames[l]
+}
+
+// MarshalText is used to output as a string for CSV rendering.
+func (l Liquidity) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText parses the string output by MarshalText.
+func (l *Liquidity) UnmarshalText(text []byte) error {
+	i, err := parseEnum(text, _liquidityNames)
+	*l = Liquidity(i)
+	return err
+}
+
+// Fill is a single execution of an order applied to a position.
+// An order may be filled by several fills, and a fill that reverses a position is split into a fill for each position.
+type Fill struct {
+	ID         DealID
+	OrderID    DealID
+	PositionID DealID
+	FilledAt   time.Time
+
+	Asset market.Asset
+	Side  OrderSide
+	Price decimal.Decimal
+	Size  decimal.Decimal
+	Fee   decimal.Decimal
+
+	Liquidity Liquidity
+
+	// RealizedPNL is the profit realized (net of the fill fee) by a fill that reduces the position, otherwise zero
+	RealizedPNL decimal.Decimal
+}
@@ -34,20 +34,61 @@ func (d *MockDealer) PlaceOrder(ctx context.Context, order Order) (*Order, *web.
 	return args.Get(0).(*Order), args.Get(1).(*web.Response), args.Error(2)
 }
 
+// PlaceBracketOrder places a bracket order.
+func (d *MockDealer) PlaceBracketOrder(ctx context.Context, bracket BracketOrder) (*BracketOrder, *web.Response, error) {
+	args := d.Called(ctx, bracket)
+
+	if len(args) == 0 {
+		return nil, nil, nil
+	}
+
+	return args.Get(0).(*BracketOrder), args.Get(1).(*web.Response), args.Error(2)
+}
+
 // CancelOrders cancels an order.
 func (d *MockDealer) CancelOrders(ctx context.Context) (*web.Response, error) {
 	args := d.Called(ctx)
 	return args.Get(0).(*web.Response), args.Error(1)
 }
 
+// CancelOrder cancels an order by ID.
+func (d *MockDealer) CancelOrder(ctx context.Context, id DealID) (*Order, *web.Response, error) {
+	args := d.Called(ctx, id)
+	return args.Get(0).(*Order), args.Get(1).(*web.Response), args.Error(2)
+}
+
+// AmendOrder amends an order by ID.
+func (d *MockDealer) AmendOrder(ctx context.Context, id DealID, opts AmendOpts) (*Order, *web.Response, error) {
+	args := d.Called(ctx, id, opts)
+	return args.Get(0).(*Order), args.Get(1).(*web.Response), args.Error(2)
+}
+
+// GetOrder returns an order by ID.
+func (d *MockDealer) GetOrder(ctx context.Context, id DealID) (*Order, *web.Response, error) {
+	args := d.Called(ctx, id)
+	return args.Get(0).(*Order), args.Get(1).(*web.Response), args.Error(2)
+}
+
+// ListOrders returns the orders of the account.
+func (d *MockDealer) ListOrders(ctx context.Context, filter *OrderFilter, opts *web.ListOpts) ([]Order, *web.Response, error) {
+	args := d.Called(ctx, filter, opts)
+	return args.Get(0).([]Order), args.Get(1).(*web.Response), args.Error(2)
+}
+
 // ListPositions returns the positions of the account.
-func (d *MockDealer) ListPositions(ctx context.Context, opts *web.ListOpts) ([]Position, *web.Response, error) {
-	args := d.Called(ctx, opts)
+func (d *MockDealer) ListPositions(ctx context.Context, filter *PositionFilter, opts *web.ListOpts) ([]Position, *web.Response, error) {
+	args := d.Called(ctx, filter, opts)
 	return args.Get(0).([]Position), args.Get(1).(*web.Response), args.Error(2)
 }
 
 // ListRoundTurns returns the round-turns of the account.
-func (d *MockDealer) ListRoundTurns(ctx context.Context, opts *web.ListOpts) ([]RoundTurn, *web.Response, error) {
-	args := d.Called(ctx, opts)
+func (d *MockDealer) ListRoundTurns(ctx context.Context, filter *RoundTurnFilter, opts *web.ListOpts) ([]RoundTurn, *web.Response, error) {
+	args := d.Called(ctx, filter, opts)
 	return args.Get(0).([]RoundTurn), args.Get(1).(*web.Response), args.Error(2)
 }
+
+// ListFills returns the fills of the account.
+func (d *MockDealer) ListFills(ctx context.Context, filter *FillFilter, opts *web.ListOpts) ([]Fill, *web.Response, error) {
+	args := d.Called(ctx, filter, opts)
+	return args.Get(0).([]Fill), args.Get(1).(*web.Response), args.Error(2)
+}
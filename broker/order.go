@@ -21,8 +21,10 @@ const (
 	Sell
 )
 
+var _orderSideNames = []string{"None", "Buy", "Sell"}
+
 func (s OrderSide) String() string {
-	return [...]string{"None", "Buy", "Sell"}[s]
+	return _orderSideNames[s]
 }
 
 // MarshalText is used to output as a string for CSV rendering.
@@ -30,6 +32,13 @@ func (s OrderSide) MarshalText() ([]byte, error) {
 	return []byte(s.String()), nil
 }
 
+// UnmarshalText parses the string output by MarshalText.
+func (s *OrderSide) UnmarshalText(text []byte) error {
+	i, err := parseEnum(text, _orderSideNames)
+	*s = OrderSide(i)
+	return err
+}
+
 // Opposite returns the opposite side of the order.
 func (s OrderSide) Opposite() OrderSide {
 	switch s {
@@ -50,10 +59,27 @@ const (
 
 	// Limit order type is executed at a specified price (maker).
 	Limit
+
+	// Stop order type becomes a Market order when the stop price is touched (taker).
+	Stop
+
+	// StopLimit order type becomes a Limit order when the stop price is touched.
+	StopLimit
+
+	// TrailingStop order type is a Stop order whose stop price trails the best price since the order opened,
+	// by a fixed distance (TrailAmount) or a fraction of the price (TrailPct).
+	TrailingStop
 )
 
+var _orderTypeNames = []string{"None", "Market", "Limit", "Stop", "StopLimit", "TrailingStop"}
+
 func (t OrderType) String() string {
-	return [...]string{"None", "Market", "Limit"}[t]
+	return _orderTypeNames[t]
+}
+
+// IsStop returns true if the order type is triggered by a stop price.
+func (t OrderType) IsStop() bool {
+	return t == Stop || t == StopLimit || t == TrailingStop
 }
 
 // MarshalText is used to output as a string for CSV rendering.
@@ -61,6 +87,52 @@ func (t OrderType) MarshalText() ([]byte, error) {
 	return []byte(t.String()), nil
 }
 
+// UnmarshalText parses the string output by MarshalText.
+func (t *OrderType) UnmarshalText(text []byte) error {
+	i, err := parseEnum(text, _orderTypeNames)
+	*t = OrderType(i)
+	return err
+}
+
+// TimeInForce represents how long an order remains active before it is cancelled.
+type TimeInForce int
+
+const (
+	// GTC (good till cancelled) orders remain open until filled or cancelled.
+	// An order with a zero TimeInForce is treated as GTC.
+	GTC TimeInForce = iota + 1
+
+	// IOC (immediate or cancel) orders fill as much as possible when placed and cancel the remainder.
+	IOC
+
+	// FOK (fill or kill) orders must fill in full when placed, otherwise the order is cancelled.
+	FOK
+
+	// GTD (good till date) orders remain open until filled, cancelled or the ExpiresAt time is reached.
+	GTD
+
+	// PostOnly orders are rejected if they would fill immediately as a taker, ensuring a maker fill.
+	PostOnly
+)
+
+var _timeInForceNames = []string{"None", "GTC", "IOC", "FOK", "GTD", "PostOnly"}
+
+func (t TimeInForce) String() string {
+	return _timeInForceNames[t]
+}
+
+// MarshalText is used to output as a string for CSV rendering.
+func (t TimeInForce) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText parses the string output by MarshalText.
+func (t *TimeInForce) UnmarshalText(text []byte) error {
+	i, err := parseEnum(text, _timeInForceNames)
+	*t = TimeInForce(i)
+	return err
+}
+
 // OrderState represents the state of an order as it is processed by a dealer.
 type OrderState int
 
@@ -84,21 +156,45 @@ func (s OrderState) String() string {
 
 // Order represents an order to be placed using a dealer.
 type Order struct {
-	ID       DealID
-	OpenedAt time.Time
-	FilledAt time.Time
-	ClosedAt time.Time
+	ID          DealID
+	OpenedAt    time.Time
+	TriggeredAt time.Time
+	FilledAt    time.Time
+	ClosedAt    time.Time
 
 	Asset      market.Asset
 	Side       OrderSide
 	Type       OrderType
 	LimitPrice decimal.Decimal
+	StopPrice  decimal.Decimal
 	Size       decimal.Decimal
 	ReduceOnly bool
 
+	// TrailAmount is the fixed distance a TrailingStop order trails the best price
+	TrailAmount decimal.Decimal
+
+	// TrailPct is the distance a TrailingStop order trails the best price as a fraction of the price, e.g. 0.05 is 5%
+	TrailPct decimal.Decimal
+
+	// TimeInForce sets how long the order remains active, defaults to GTC
+	TimeInForce TimeInForce
+
+	// ExpiresAt is the time a GTD order is cancelled if not filled
+	ExpiresAt time.Time
+
+	// ParentID is the ID of the entry order when the order is a leg of a BracketOrder
+	ParentID DealID
+
+	// PositionSide is the side of the position the order applies to when a dealer tracks long and short positions
+	// independently (hedge mode): Buy (long) or Sell (short). Ignored when positions are netted (one-way mode).
+	PositionSide OrderSide
+
 	FilledPrice decimal.Decimal
 	FilledSize  decimal.Decimal
 
+	// Liquidity is whether the latest fill of the order rested on the book (maker) or crossed it (taker)
+	Liquidity Liquidity
+
 	Fee decimal.Decimal
 }
 
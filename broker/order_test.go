@@ -0,0 +1,41 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package broker
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestOrderJSONRoundTrip(t *testing.T) {
+	give := Order{
+		ID:          "1",
+		OpenedAt:    time.UnixMilli(1000).UTC(),
+		Asset:       market.NewAsset("BTCUSD"),
+		Side:        Sell,
+		Type:        TrailingStop,
+		TimeInForce: GTD,
+		Size:        dec.New(1.5),
+	}
+	data, err := json.Marshal(give)
+	assert.NoError(t, err)
+
+	var act Order
+	assert.NoError(t, json.Unmarshal(data, &act))
+	assert.Equal(t, give.Side, act.Side)
+	assert.Equal(t, give.Type, act.Type)
+	assert.Equal(t, give.TimeInForce, act.TimeInForce)
+	assert.Equal(t, give.OpenedAt, act.OpenedAt)
+	assert.True(t, give.Size.Equal(act.Size))
+}
+
+func TestOrderSideUnmarshalTextUnknown(t *testing.T) {
+	var side OrderSide
+	assert.ErrorIs(t, side.UnmarshalText([]byte("Long")), ErrUnknownEnumText)
+}
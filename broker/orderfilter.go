@@ -0,0 +1,50 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package broker
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/market"
+	"golang.org/x/exp/slices"
+)
+
+// OrderFilter is used to filter the orders returned by a Dealer.
+// Zero value fields are not applied.
+type OrderFilter struct {
+	Asset  market.Asset
+	Side   OrderSide
+	States []OrderState
+
+	// From and To select orders opened in the time range [From, To)
+	From time.Time
+	To   time.Time
+}
+
+// Match returns true if the order satisfies the filter.
+// A nil filter matches all orders.
+func (f *OrderFilter) Match(order Order) bool {
+	if f == nil {
+		return true
+	}
+	if f.Asset != (market.Asset{}) && !order.Asset.Equal(f.Asset) {
+		return false
+	}
+	if f.Side != 0 && order.Side != f.Side {
+		return false
+	}
+	if len(f.States) > 0 && !slices.Contains(f.States, order.State()) {
+		return false
+	}
+	return inTimeRange(order.OpenedAt, f.From, f.To)
+}
+
+// AmendOpts is used to amend an open order.
+// Zero value fields are left unchanged.
+type AmendOpts struct {
+	LimitPrice decimal.Decimal
+	StopPrice  decimal.Decimal
+	Size       decimal.Decimal
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+func TestOrderFilter_Match(t *testing.T) {
+	btc := market.NewAsset("BTCUSD")
+	fixed := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	open := Order{Asset: btc, Side: Buy, OpenedAt: fixed}
+
+	tests := []struct {
+		name       string
+		giveFilter *OrderFilter
+		giveOrder  Order
+		want       bool
+	}{
+		{
+			name:       "nil filter",
+			giveFilter: nil,
+			giveOrder:  open,
+			want:       true,
+		},
+		{
+			name:       "match asset and state",
+			giveFilter: &OrderFilter{Asset: btc, States: []OrderState{OrderOpen}},
+			giveOrder:  open,
+			want:       true,
+		},
+		{
+			name:       "no match asset",
+			giveFilter: &OrderFilter{Asset: market.NewAsset("ETHUSD")},
+			giveOrder:  open,
+			want:       false,
+		},
+		{
+			name:       "no match state",
+			giveFilter: &OrderFilter{States: []OrderState{OrderFilled, OrderClosed}},
+			giveOrder:  open,
+			want:       false,
+		},
+		{
+			name:       "no match side",
+			giveFilter: &OrderFilter{Side: Sell},
+			giveOrder:  open,
+			want:       false,
+		},
+		{
+			name:       "match time range",
+			giveFilter: &OrderFilter{From: fixed, To: fixed.Add(time.Hour)},
+			giveOrder:  open,
+			want:       true,
+		},
+		{
+			name:       "no match time range end is exclusive",
+			giveFilter: &OrderFilter{From: fixed.Add(-time.Hour), To: fixed},
+			giveOrder:  open,
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.giveFilter.Match(tt.giveOrder))
+		})
+	}
+}
@@ -51,9 +51,15 @@ type Position struct {
 	// MarkPrice is the latest marked price for the asset
 	MarkPrice decimal.Decimal
 
-	// PNL is Size * (MarkPrice - EntryPrice)
+	// PNL is the total profit of the position i.e. RealizedPNL + UnrealizedPNL
 	PNL decimal.Decimal
 
+	// RealizedPNL is the profit realized by fills that reduced the position, net of their fees
+	RealizedPNL decimal.Decimal
+
+	// UnrealizedPNL is Size * (MarkPrice - EntryPrice), negated for a short position
+	UnrealizedPNL decimal.Decimal
+
 	// Exit price is the price at which the position was closed
 	ExitPrice decimal.Decimal
 }
@@ -35,18 +35,48 @@ func (d *StubDealer) PlaceOrder(ctx context.Context, order Order) (*Order, *web.
 	return nil, nil, nil
 }
 
+// PlaceBracketOrder not implemented.
+func (d *StubDealer) PlaceBracketOrder(ctx context.Context, bracket BracketOrder) (*BracketOrder, *web.Response, error) {
+	return nil, nil, nil
+}
+
 // CancelOrders not implemented.
 func (d *StubDealer) CancelOrders(ctx context.Context) (*web.Response, error) {
 	return nil, nil
 }
 
+// CancelOrder not implemented.
+func (d *StubDealer) CancelOrder(ctx context.Context, id DealID) (*Order, *web.Response, error) {
+	return nil, nil, nil
+}
+
+// AmendOrder not implemented.
+func (d *StubDealer) AmendOrder(ctx context.Context, id DealID, opts AmendOpts) (*Order, *web.Response, error) {
+	return nil, nil, nil
+}
+
+// GetOrder not implemented.
+func (d *StubDealer) GetOrder(ctx context.Context, id DealID) (*Order, *web.Response, error) {
+	return nil, nil, nil
+}
+
+// ListOrders not implemented.
+func (d *StubDealer) ListOrders(ctx context.Context, filter *OrderFilter, opts *web.ListOpts) ([]Order, *web.Response, error) {
+	return nil, nil, nil
+}
+
 // ListPositions not implemented.
-func (d *StubDealer) ListPositions(ctx context.Context, opts *web.ListOpts) ([]Position, *web.Response, error) {
+func (d *StubDealer) ListPositions(ctx context.Context, filter *PositionFilter, opts *web.ListOpts) ([]Position, *web.Response, error) {
 	return nil, nil, nil
 }
 
 // ListRoundTurns not implemented.
-func (d *StubDealer) ListRoundTurns(ctx context.Context, opts *web.ListOpts) ([]RoundTurn, *web.Response, error) {
+func (d *StubDealer) ListRoundTurns(ctx context.Context, filter *RoundTurnFilter, opts *web.ListOpts) ([]RoundTurn, *web.Response, error) {
+	return nil, nil, nil
+}
+
+// ListFills not implemented.
+func (d *StubDealer) ListFills(ctx context.Context, filter *FillFilter, opts *web.ListOpts) ([]Fill, *web.Response, error) {
 	return nil, nil, nil
 }
 
@@ -0,0 +1,75 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"encoding/csv"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrHeaderRecord is returned by a CSVTradeDecoder when the CSV record is a column header.
+var ErrHeaderRecord = errors.New("record is a column header")
+
+// ErrInvalidSideFormat is returned when the CSV trade record does not have a valid aggressor side format.
+var ErrInvalidSideFormat = errors.New("side must be in valid bool format")
+
+// CSVTradeDecoder is an extension point for CSVTradeReader to support custom file formats.
+type CSVTradeDecoder func(record []string) (Trade, error)
+
+// NewBinanceCSVTradeReader creates a new CSVTradeReader for Binance aggTrades CSV files.
+func NewBinanceCSVTradeReader(csv *csv.Reader) *CSVTradeReader {
+	return &CSVTradeReader{
+		csv:     csv,
+		decoder: BinanceCSVTradeDecoder,
+	}
+}
+
+// BinanceCSVTradeDecoder decodes a CSV record from a Binance aggTrades dump into a Trade.
+// Expected columns: agg_trade_id, price, quantity, first_trade_id, last_trade_id, transact_time, is_buyer_maker.
+// Spot dumps without a header and futures dumps with a header are both supported.
+func BinanceCSVTradeDecoder(record []string) (Trade, error) {
+	var t, empty Trade
+	var err error
+
+	if len(record) < 7 {
+		return empty, ErrNotEnoughColumns
+	}
+
+	if record[0] == "agg_trade_id" {
+		return empty, ErrHeaderRecord
+	}
+
+	if t.Price, err = decimal.NewFromString(record[1]); err != nil {
+		return empty, ErrInvalidPriceFormat
+	}
+	if t.Size, err = decimal.NewFromString(record[2]); err != nil {
+		return empty, ErrInvalidVolumeFormat
+	}
+
+	ts, err := strconv.ParseInt(record[5], 10, 64)
+	if err != nil {
+		return empty, ErrInvalidTimeFormat
+	}
+	// Later spot dumps use microsecond timestamps
+	if ts > 1e14 {
+		t.Time = time.UnixMicro(ts).UTC()
+	} else {
+		t.Time = time.UnixMilli(ts).UTC()
+	}
+
+	isBuyerMaker, err := strconv.ParseBool(record[6])
+	if err != nil {
+		return empty, ErrInvalidSideFormat
+	}
+	t.Side = TakerBuy
+	if isBuyerMaker {
+		t.Side = TakerSell
+	}
+
+	return t, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+)
+
+var _ TradeReader = (*CSVTradeReader)(nil)
+
+// CSVTradeReader is a TradeReader that reads from a CSV file.
+type CSVTradeReader struct {
+	csv     *csv.Reader
+	decoder CSVTradeDecoder
+}
+
+// MakeCSVTradeReader is a factory method type that creates a new CSVTradeReader.
+type MakeCSVTradeReader func(csv *csv.Reader) *CSVTradeReader
+
+// NewCSVTradeReader creates a new CSVTradeReader with the default Binance decoder.
+func NewCSVTradeReader(csv *csv.Reader) *CSVTradeReader {
+	return &CSVTradeReader{
+		csv:     csv,
+		decoder: BinanceCSVTradeDecoder,
+	}
+}
+
+// NewCSVTradeReaderWithDecoder creates a new CSVTradeReader with the given decoder.
+func NewCSVTradeReaderWithDecoder(csv *csv.Reader, decoder CSVTradeDecoder) *CSVTradeReader {
+	return &CSVTradeReader{
+		csv:     csv,
+		decoder: decoder,
+	}
+}
+
+// Read reads the next Trade from the underlying CSV data.
+// Header records are skipped.
+func (r *CSVTradeReader) Read() (Trade, error) {
+	for {
+		rec, err := r.csv.Read()
+		if err != nil {
+			return Trade{}, err
+		}
+		trade, err := r.decoder(rec)
+		if errors.Is(err, ErrHeaderRecord) {
+			continue
+		}
+		return trade, err
+	}
+}
+
+// ReadAll reads all the Trades from the underlying CSV data.
+func (r *CSVTradeReader) ReadAll() ([]Trade, error) {
+	var ts []Trade
+	for {
+		t, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, t)
+	}
+
+	return ts, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/gou/dec"
+)
+
+var assertTradeEq = func(t *testing.T, exp, act Trade) {
+	assert.Equal(t, exp.Time, act.Time)
+	assert.True(t, exp.Price.Equal(act.Price))
+	assert.True(t, exp.Size.Equal(act.Size))
+	assert.Equal(t, exp.Side, act.Side)
+}
+
+func TestCSVTradeReader_ReadWithBinanceDecoder(t *testing.T) {
+	tests := []struct {
+		name string
+		give string
+		want Trade
+		err  error
+	}{
+		{
+			name: "Read buyer maker",
+			give: "598208826,28923.63,0.012,646381311,646381312,1609459200123,True,True",
+			want: Trade{
+				Time:  time.UnixMilli(1609459200123).UTC(),
+				Price: dec.New(28923.63),
+				Size:  dec.New(0.012),
+				Side:  TakerSell,
+			},
+		},
+		{
+			name: "Read futures with header",
+			give: "agg_trade_id,price,quantity,first_trade_id,last_trade_id,transact_time,is_buyer_maker\n" +
+				"598208826,28923.63,0.012,646381311,646381312,1609459200123,false",
+			want: Trade{
+				Time:  time.UnixMilli(1609459200123).UTC(),
+				Price: dec.New(28923.63),
+				Size:  dec.New(0.012),
+				Side:  TakerBuy,
+			},
+		},
+		{
+			name: "Read microsecond time",
+			give: "598208826,28923.63,0.012,646381311,646381312,1609459200123456,false",
+			want: Trade{
+				Time:  time.UnixMicro(1609459200123456).UTC(),
+				Price: dec.New(28923.63),
+				Size:  dec.New(0.012),
+				Side:  TakerBuy,
+			},
+		},
+		{
+			name: "Not enough columns",
+			give: "598208826,28923.63,0.012",
+			err:  ErrNotEnoughColumns,
+		},
+		{
+			name: "Invalid time format",
+			give: "598208826,28923.63,0.012,646381311,646381312,23/12/2021,false",
+			err:  ErrInvalidTimeFormat,
+		},
+		{
+			name: "Invalid price format",
+			give: "598208826,sixty,0.012,646381311,646381312,1609459200123,false",
+			err:  ErrInvalidPriceFormat,
+		},
+		{
+			name: "Invalid side format",
+			give: "598208826,28923.63,0.012,646381311,646381312,1609459200123,maker",
+			err:  ErrInvalidSideFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewBinanceCSVTradeReader(csv.NewReader(strings.NewReader(tt.give)))
+			trade, err := reader.Read()
+			assert.Equal(t, tt.err, err)
+			assertTradeEq(t, tt.want, trade)
+		})
+	}
+}
+
+func TestCSVTradeReader_ReadAllWithDefaultDecoder(t *testing.T) {
+	records := []string{
+		"agg_trade_id,price,quantity,first_trade_id,last_trade_id,transact_time,is_buyer_maker",
+		"598208826,28923.63,0.012,646381311,646381312,1609459200123,true",
+		"598208827,28924.00,1.5,646381313,646381315,1609459200200,false",
+	}
+	reader := NewCSVTradeReader(csv.NewReader(strings.NewReader(strings.Join(records, "\n"))))
+	trades, err := reader.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, trades, 2)
+}
@@ -0,0 +1,144 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrNoConversionRate is returned when there is no rate to convert between two currencies.
+var ErrNoConversionRate = errors.New("no conversion rate")
+
+// CurrencyPair is the base and quote currency of an asset, e.g. BTC and USDT for BTCUSDT.
+// The price of the asset is the amount of the quote currency per unit of the base currency.
+type CurrencyPair struct {
+	Base  string
+	Quote string
+}
+
+// ConversionRate is the rate of a currency pair at a time.
+type ConversionRate struct {
+	Pair CurrencyPair
+	Rate decimal.Decimal
+}
+
+// Converter converts amounts between currencies at the latest rate of each currency pair.
+// Rates are fed from the close price of klines for assets with a known currency pair,
+// and currencies without a direct pair are converted through intermediate currencies.
+type Converter struct {
+	pairs map[Asset]CurrencyPair
+	rates map[CurrencyPair]decimal.Decimal
+}
+
+// NewConverter creates a new Converter without pairs or rates.
+func NewConverter() *Converter {
+	return &Converter{
+		pairs: make(map[Asset]CurrencyPair),
+		rates: make(map[CurrencyPair]decimal.Decimal),
+	}
+}
+
+// SetPair sets the currency pair of an asset.
+func (c *Converter) SetPair(asset Asset, pair CurrencyPair) {
+	c.pairs[asset] = pair
+}
+
+// Pair returns the currency pair of an asset, or false if the asset has no pair.
+func (c *Converter) Pair(asset Asset) (CurrencyPair, bool) {
+	pair, ok := c.pairs[asset]
+	return pair, ok
+}
+
+// Assets returns the assets with a currency pair sorted by symbol.
+func (c *Converter) Assets() []Asset {
+	assets := make([]Asset, 0, len(c.pairs))
+	for asset := range c.pairs {
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Symbol < assets[j].Symbol })
+	return assets
+}
+
+// ReceivePrice updates the rate of the asset currency pair to the close price of the kline.
+// An empty asset is the default price feed, and updates the pair set for the empty asset.
+// Prices for assets without a pair are ignored.
+func (c *Converter) ReceivePrice(asset Asset, price Kline) {
+	if pair, ok := c.pairs[asset]; ok {
+		c.SetRate(pair, price.C)
+	}
+}
+
+// SetRate sets the rate of a currency pair. A rate that is not positive is ignored.
+func (c *Converter) SetRate(pair CurrencyPair, rate decimal.Decimal) {
+	if rate.IsPositive() {
+		c.rates[pair] = rate
+	}
+}
+
+// Rates returns the latest rate of each currency pair sorted by pair.
+func (c *Converter) Rates() []ConversionRate {
+	rates := make([]ConversionRate, 0, len(c.rates))
+	for pair, rate := range c.rates {
+		rates = append(rates, ConversionRate{Pair: pair, Rate: rate})
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Pair.Base != rates[j].Pair.Base {
+			return rates[i].Pair.Base < rates[j].Pair.Base
+		}
+		return rates[i].Pair.Quote < rates[j].Pair.Quote
+	})
+	return rates
+}
+
+// Convert converts an amount in one currency to another using the fewest conversions.
+// Returns ErrNoConversionRate if the currencies are not connected by known rates.
+func (c *Converter) Convert(amount decimal.Decimal, from, to string) (decimal.Decimal, error) {
+	if from == to {
+		return amount, nil
+	}
+
+	// Breadth first search of the currency graph, tracking the rate from the source currency
+	visited := map[string]decimal.Decimal{from: decimal.NewFromInt(1)}
+	queue := []string{from}
+	for len(queue) > 0 {
+		currency := queue[0]
+		queue = queue[1:]
+		for _, next := range c.neighbours(currency) {
+			if _, ok := visited[next.currency]; ok {
+				continue
+			}
+			rate := visited[currency].Mul(next.rate)
+			if next.currency == to {
+				return amount.Mul(rate), nil
+			}
+			visited[next.currency] = rate
+			queue = append(queue, next.currency)
+		}
+	}
+	return decimal.Zero, ErrNoConversionRate
+}
+
+// conversion is the rate to convert one unit of a currency to another currency.
+type conversion struct {
+	currency string
+	rate     decimal.Decimal
+}
+
+// neighbours returns the currencies directly convertible from the given currency, sorted for a deterministic search.
+func (c *Converter) neighbours(currency string) []conversion {
+	var out []conversion
+	for pair, rate := range c.rates {
+		switch currency {
+		case pair.Base:
+			out = append(out, conversion{currency: pair.Quote, rate: rate})
+		case pair.Quote:
+			out = append(out, conversion{currency: pair.Base, rate: decimal.NewFromInt(1).Div(rate)})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].currency < out[j].currency })
+	return out
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestConverter_Convert(t *testing.T) {
+	converter := NewConverter()
+	converter.SetPair(NewAsset("BTCUSDT"), CurrencyPair{Base: "BTC", Quote: "USDT"})
+	converter.SetPair(NewAsset("BTCEUR"), CurrencyPair{Base: "BTC", Quote: "EUR"})
+	converter.SetPair(NewAsset("ETHBTC"), CurrencyPair{Base: "ETH", Quote: "BTC"})
+	converter.ReceivePrice(NewAsset("BTCUSDT"), Kline{C: dec.New(20000)})
+	converter.ReceivePrice(NewAsset("BTCEUR"), Kline{C: dec.New(16000)})
+	converter.ReceivePrice(NewAsset("ETHBTC"), Kline{C: dec.New(0.1)})
+	converter.ReceivePrice(NewAsset("SOLUSDT"), Kline{C: dec.New(30)})
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		want    decimal.Decimal
+		wantErr error
+	}{
+		{
+			name: "same currency",
+			from: "XYZ",
+			to:   "XYZ",
+			want: dec.New(2),
+		},
+		{
+			name: "direct",
+			from: "BTC",
+			to:   "USDT",
+			want: dec.New(40000),
+		},
+		{
+			name: "inverse",
+			from: "USDT",
+			to:   "BTC",
+			want: dec.New(0.0001),
+		},
+		{
+			name: "through intermediate currency",
+			from: "EUR",
+			to:   "USDT",
+			want: dec.New(2.5),
+		},
+		{
+			name: "through two intermediate currencies",
+			from: "ETH",
+			to:   "EUR",
+			want: dec.New(3200),
+		},
+		{
+			name:    "no rate",
+			from:    "SOL",
+			to:      "USDT",
+			wantErr: ErrNoConversionRate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := converter.Convert(dec.New(2), tt.from, tt.to)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.True(t, act.Round(8).Equal(tt.want), act.String())
+		})
+	}
+}
+
+func TestConverter_Rates(t *testing.T) {
+	converter := NewConverter()
+	converter.SetRate(CurrencyPair{Base: "ETH", Quote: "USDT"}, dec.New(1500))
+	converter.SetRate(CurrencyPair{Base: "BTC", Quote: "USDT"}, dec.New(20000))
+	converter.SetRate(CurrencyPair{Base: "BTC", Quote: "EUR"}, decimal.Zero)
+
+	act := converter.Rates()
+	assert.Len(t, act, 2)
+	assert.Equal(t, "BTC", act[0].Pair.Base)
+	assert.Equal(t, "ETH", act[1].Pair.Base)
+}
+
+func TestConverter_ReceivePrice(t *testing.T) {
+	converter := NewConverter()
+	converter.SetPair(NewAsset("BTCEUR"), CurrencyPair{Base: "BTC", Quote: "EUR"})
+	converter.SetPair(Asset{}, CurrencyPair{Base: "BTC", Quote: "USDT"})
+	converter.ReceivePrice(Asset{}, Kline{C: dec.New(20000)})
+	converter.ReceivePrice(NewAsset("SOLUSDT"), Kline{C: dec.New(30)})
+
+	act := converter.Rates()
+	assert.Len(t, act, 1)
+	assert.Equal(t, CurrencyPair{Base: "BTC", Quote: "USDT"}, act[0].Pair)
+	assert.True(t, act[0].Rate.Equal(dec.New(20000)))
+
+	assert.Equal(t, []Asset{{}, NewAsset("BTCEUR")}, converter.Assets())
+}
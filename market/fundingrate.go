@@ -0,0 +1,111 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"encoding/csv"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// FundingRate is the funding rate of a perpetual future settled at the given time.
+// A positive rate is paid by longs to shorts.
+type FundingRate struct {
+	Time time.Time
+	Rate decimal.Decimal
+}
+
+// CSVFundingRateDecoder is an extension point to support custom funding rate file formats.
+type CSVFundingRateDecoder func(record []string) (FundingRate, error)
+
+// BinanceCSVFundingRateDecoder decodes a CSV record from a Binance fundingRate dump into a FundingRate.
+// Expected columns: calc_time, funding_interval_hours, last_funding_rate.
+func BinanceCSVFundingRateDecoder(record []string) (FundingRate, error) {
+	var f, empty FundingRate
+	var err error
+
+	if len(record) < 3 {
+		return empty, ErrNotEnoughColumns
+	}
+
+	if record[0] == "calc_time" {
+		return empty, ErrHeaderRecord
+	}
+
+	msec, err := strconv.ParseInt(record[0], 10, 64)
+	if err != nil {
+		return empty, ErrInvalidTimeFormat
+	}
+	f.Time = time.UnixMilli(msec).UTC()
+
+	if f.Rate, err = decimal.NewFromString(record[2]); err != nil {
+		return empty, ErrInvalidPriceFormat
+	}
+
+	return f, nil
+}
+
+// ReadFundingRatesFromCSV reads all the .csv files in a given directory or a single file into a slice of FundingRates
+// sorted by time, using the Binance fundingRate decoder.
+func ReadFundingRatesFromCSV(path string) ([]FundingRate, error) {
+	return ReadFundingRatesFromCSVWithDecoder(path, BinanceCSVFundingRateDecoder)
+}
+
+// ReadFundingRatesFromCSVWithDecoder permits using a custom decoder.
+func ReadFundingRatesFromCSVWithDecoder(path string, decoder CSVFundingRateDecoder) ([]FundingRate, error) {
+	var rates []FundingRate
+
+	err := filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if filepath.Ext(path) != ".csv" {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		//nolint:errcheck // Read ops only so safe to ignore err return
+		defer file.Close()
+		reader := csv.NewReader(file)
+		for {
+			rec, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			rate, err := decoder(rec)
+			if err == ErrHeaderRecord {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			rates = append(rates, rate)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(rates, func(i, j int) bool {
+		return rates[i].Time.Before(rates[j].Time)
+	})
+
+	return rates, nil
+}
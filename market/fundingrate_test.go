@@ -0,0 +1,57 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestBinanceCSVFundingRateDecoder(t *testing.T) {
+	tests := []struct {
+		name string
+		give []string
+		want FundingRate
+		err  error
+	}{
+		{
+			name: "Read rate",
+			give: []string{"1640995200000", "8", "-0.00005000"},
+			want: FundingRate{Time: time.UnixMilli(1640995200000).UTC(), Rate: dec.New(-0.00005)},
+		},
+		{
+			name: "Header",
+			give: []string{"calc_time", "funding_interval_hours", "last_funding_rate"},
+			err:  ErrHeaderRecord,
+		},
+		{
+			name: "Not enough columns",
+			give: []string{"1640995200000", "8"},
+			err:  ErrNotEnoughColumns,
+		},
+		{
+			name: "Invalid time format",
+			give: []string{"01/01/2022", "8", "0.0001"},
+			err:  ErrInvalidTimeFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := BinanceCSVFundingRateDecoder(tt.give)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.want.Time, act.Time)
+			assert.True(t, tt.want.Rate.Equal(act.Rate))
+		})
+	}
+}
+
+func TestReadFundingRatesFromCSV(t *testing.T) {
+	rates, err := ReadFundingRatesFromCSV("./testdata/fundingrate/")
+	assert.NoError(t, err)
+	assert.Len(t, rates, 3)
+	assert.True(t, rates[1].Rate.Equal(dec.New(-0.00005)))
+}
@@ -0,0 +1,162 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BookLevel is the total size resting at a price level of an order book.
+type BookLevel struct {
+	Price decimal.Decimal
+	Size  decimal.Decimal
+}
+
+// DepthUpdate is a change to the levels of an order book.
+// A level with zero size removes the price level from the book.
+type DepthUpdate struct {
+	Time time.Time
+	Bids []BookLevel
+	Asks []BookLevel
+}
+
+// OrderBook is a level 2 (price aggregated) order book.
+// Bids are sorted best (highest) first and asks are sorted best (lowest) first.
+type OrderBook struct {
+	Time time.Time
+	Bids []BookLevel
+	Asks []BookLevel
+}
+
+// NewOrderBook creates a new OrderBook from a depth snapshot.
+func NewOrderBook(snapshot DepthUpdate) *OrderBook {
+	book := &OrderBook{}
+	book.Apply(snapshot)
+	return book
+}
+
+// Apply applies a depth update (diff) to the book.
+func (b *OrderBook) Apply(update DepthUpdate) {
+	b.Time = update.Time
+	b.Bids = applyLevels(b.Bids, update.Bids, func(p1, p2 decimal.Decimal) bool { return p1.GreaterThan(p2) })
+	b.Asks = applyLevels(b.Asks, update.Asks, func(p1, p2 decimal.Decimal) bool { return p1.LessThan(p2) })
+}
+
+// Clone returns a deep copy of the book.
+func (b *OrderBook) Clone() *OrderBook {
+	clone := *b
+	clone.Bids = append([]BookLevel(nil), b.Bids...)
+	clone.Asks = append([]BookLevel(nil), b.Asks...)
+	return &clone
+}
+
+// BestBid returns the best bid level, or false if there are no bids.
+func (b *OrderBook) BestBid() (BookLevel, bool) {
+	if len(b.Bids) == 0 {
+		return BookLevel{}, false
+	}
+	return b.Bids[0], true
+}
+
+// BestAsk returns the best ask level, or false if there are no asks.
+func (b *OrderBook) BestAsk() (BookLevel, bool) {
+	if len(b.Asks) == 0 {
+		return BookLevel{}, false
+	}
+	return b.Asks[0], true
+}
+
+// Mid returns the mid price of the best bid and ask, or zero if either side is empty.
+func (b *OrderBook) Mid() decimal.Decimal {
+	bid, okBid := b.BestBid()
+	ask, okAsk := b.BestAsk()
+	if !okBid || !okAsk {
+		return decimal.Zero
+	}
+	return decimal.Avg(bid.Price, ask.Price)
+}
+
+// Walk returns the volume weighted average price and size a taker order of the given size would fill at,
+// walking the opposite side of the book from the best level. The size filled is capped at the depth of the book.
+// A TakerBuy walks the asks and a TakerSell walks the bids. The book is not modified.
+func (b *OrderBook) Walk(side TradeSide, size decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	return walkLevels(b.levels(side), size)
+}
+
+// Take is the same as Walk but removes the filled size from the book.
+func (b *OrderBook) Take(side TradeSide, size decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	levels := b.levels(side)
+	price, filled := walkLevels(levels, size)
+
+	remaining := filled
+	for len(levels) > 0 && remaining.IsPositive() {
+		take := decimal.Min(levels[0].Size, remaining)
+		remaining = remaining.Sub(take)
+		levels[0].Size = levels[0].Size.Sub(take)
+		if !levels[0].Size.IsPositive() {
+			levels = levels[1:]
+		}
+	}
+
+	switch side {
+	case TakerBuy:
+		b.Asks = levels
+	case TakerSell:
+		b.Bids = levels
+	}
+	return price, filled
+}
+
+// levels returns the side of the book a taker on the given side trades against.
+func (b *OrderBook) levels(side TradeSide) []BookLevel {
+	switch side {
+	case TakerBuy:
+		return b.Asks
+	case TakerSell:
+		return b.Bids
+	}
+	return nil
+}
+
+// walkLevels returns the volume weighted average price and filled size of walking the levels in sequence.
+func walkLevels(levels []BookLevel, size decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	var notional, filled decimal.Decimal
+	for _, level := range levels {
+		remaining := size.Sub(filled)
+		if !remaining.IsPositive() {
+			break
+		}
+		take := decimal.Min(level.Size, remaining)
+		notional = notional.Add(take.Mul(level.Price))
+		filled = filled.Add(take)
+	}
+	if filled.IsZero() {
+		return decimal.Zero, decimal.Zero
+	}
+	return notional.Div(filled), filled
+}
+
+// applyLevels upserts the updates into the levels, removing levels with zero size, and sorts by the given order.
+func applyLevels(levels, updates []BookLevel, better func(p1, p2 decimal.Decimal) bool) []BookLevel {
+	for _, update := range updates {
+		i := sort.Search(len(levels), func(i int) bool {
+			return !better(levels[i].Price, update.Price)
+		})
+		exists := i < len(levels) && levels[i].Price.Equal(update.Price)
+		switch {
+		case exists && !update.Size.IsPositive():
+			levels = append(levels[:i], levels[i+1:]...)
+		case exists:
+			levels[i].Size = update.Size
+		case update.Size.IsPositive():
+			levels = append(levels, BookLevel{})
+			copy(levels[i+1:], levels[i:])
+			levels[i] = update
+		}
+	}
+	return levels
+}
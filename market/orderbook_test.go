@@ -0,0 +1,100 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func newTestOrderBook() *OrderBook {
+	return NewOrderBook(DepthUpdate{
+		Bids: []BookLevel{
+			{Price: dec.New(98), Size: dec.New(2)},
+			{Price: dec.New(99), Size: dec.New(1)},
+		},
+		Asks: []BookLevel{
+			{Price: dec.New(102), Size: dec.New(2)},
+			{Price: dec.New(101), Size: dec.New(1)},
+		},
+	})
+}
+
+func TestOrderBook_Apply(t *testing.T) {
+	book := newTestOrderBook()
+	book.Apply(DepthUpdate{
+		Bids: []BookLevel{
+			{Price: dec.New(99), Size: dec.New(0)},
+			{Price: dec.New(100), Size: dec.New(3)},
+		},
+		Asks: []BookLevel{
+			{Price: dec.New(102), Size: dec.New(5)},
+			{Price: dec.New(103), Size: dec.New(1)},
+		},
+	})
+
+	assert.Equal(t, []string{"100:3", "98:2"}, levelStrings(book.Bids))
+	assert.Equal(t, []string{"101:1", "102:5", "103:1"}, levelStrings(book.Asks))
+	assert.True(t, book.Mid().Equal(dec.New(100.5)))
+}
+
+func TestOrderBook_Walk(t *testing.T) {
+	tests := []struct {
+		name       string
+		giveSide   TradeSide
+		giveSize   decimal.Decimal
+		wantPrice  decimal.Decimal
+		wantFilled decimal.Decimal
+	}{
+		{
+			name:       "buy within best level",
+			giveSide:   TakerBuy,
+			giveSize:   dec.New(0.5),
+			wantPrice:  dec.New(101),
+			wantFilled: dec.New(0.5),
+		},
+		{
+			name:       "buy across levels",
+			giveSide:   TakerBuy,
+			giveSize:   dec.New(2),
+			wantPrice:  dec.New(101.5),
+			wantFilled: dec.New(2),
+		},
+		{
+			name:       "sell capped at depth",
+			giveSide:   TakerSell,
+			giveSize:   dec.New(10),
+			wantPrice:  decimal.NewFromInt(295).Div(decimal.NewFromInt(3)),
+			wantFilled: dec.New(3),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := newTestOrderBook()
+			price, filled := book.Walk(tt.giveSide, tt.giveSize)
+			assert.True(t, price.Equal(tt.wantPrice), price.String())
+			assert.True(t, filled.Equal(tt.wantFilled), filled.String())
+		})
+	}
+}
+
+func TestOrderBook_Take(t *testing.T) {
+	book := newTestOrderBook()
+	price, filled := book.Take(TakerBuy, dec.New(2))
+	assert.True(t, price.Equal(dec.New(101.5)))
+	assert.True(t, filled.Equal(dec.New(2)))
+	assert.Equal(t, []string{"102:1"}, levelStrings(book.Asks))
+	assert.Equal(t, []string{"99:1", "98:2"}, levelStrings(book.Bids))
+}
+
+func levelStrings(levels []BookLevel) []string {
+	var s []string
+	for _, level := range levels {
+		s = append(s, level.Price.String()+":"+level.Size.String())
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"encoding/csv"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ReadTradesFromCSV reads all the .csv files in a given directory or a single file into a slice of Trades.
+// Wraps a default CSVTradeReader with Binance aggTrades decoder for convenience.
+func ReadTradesFromCSV(path string) ([]Trade, error) {
+	return ReadTradesFromCSVWithDecoder(path, MakeCSVTradeReader(NewBinanceCSVTradeReader))
+}
+
+// ReadTradesFromCSVWithDecoder permits using a custom CSVTradeReader.
+func ReadTradesFromCSVWithDecoder(path string, maker MakeCSVTradeReader) ([]Trade, error) {
+	var trades []Trade
+
+	err := filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if filepath.Ext(path) != ".csv" {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		//nolint:errcheck // Read ops only so safe to ignore err return
+		defer file.Close()
+		reader := maker(csv.NewReader(file))
+		ts, err := reader.ReadAll()
+		if err != nil {
+			return err
+		}
+		trades = append(trades, ts...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return trades, nil
+}
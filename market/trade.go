@@ -0,0 +1,47 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TradeSide is the side of the aggressor (taker) of a trade.
+type TradeSide int
+
+const (
+	// TakerBuy is a trade where the buyer is the aggressor, lifting a resting sell order.
+	TakerBuy TradeSide = iota + 1
+
+	// TakerSell is a trade where the seller is the aggressor, hitting a resting buy order.
+	TakerSell
+)
+
+// String returns the string representation of the trade side.
+func (s TradeSide) String() string {
+	return [...]string{"", "TakerBuy", "TakerSell"}[s]
+}
+
+// Trade represents a single trade print.
+type Trade struct {
+	Time  time.Time
+	Price decimal.Decimal
+	Size  decimal.Decimal
+	Side  TradeSide
+}
+
+// Kline returns a kline spanning only the trade price,
+// for use with APIs that process klines.
+func (t Trade) Kline() Kline {
+	return Kline{
+		Start:  t.Time,
+		O:      t.Price,
+		H:      t.Price,
+		L:      t.Price,
+		C:      t.Price,
+		Volume: t.Size.InexactFloat64(),
+	}
+}
@@ -0,0 +1,10 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+// TradeReader is an interface for reading trade prints.
+type TradeReader interface {
+	Read() (Trade, error)
+	ReadAll() ([]Trade, error)
+}
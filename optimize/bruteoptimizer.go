@@ -278,7 +278,7 @@ func runBacktest(ctx context.Context, bot trader.Bot, dealer broker.SimulatedDea
 		return empty, err
 	}
 
-	roundturns, _, err := dealer.ListRoundTurns(context.Background(), nil)
+	roundturns, _, err := dealer.ListRoundTurns(context.Background(), nil, nil)
 	if err != nil {
 		return empty, err
 	}
@@ -137,7 +137,8 @@ func (b *Bot) signal(prediction float64) (enter, exit broker.OrderSide) {
 func (b *Bot) getOpenPosition(ctx context.Context, side broker.OrderSide) (broker.Position, error) {
 	var empty broker.Position
 
-	positions, _, err := b.dealer.ListPositions(ctx, nil)
+	filter := broker.PositionFilter{Asset: b.Asset, Side: side, States: []broker.PositionState{broker.PositionOpen}}
+	positions, _, err := b.dealer.ListPositions(ctx, &filter, nil)
 	if err != nil {
 		return empty, err
 	}
@@ -170,7 +171,7 @@ func (b *Bot) exit(ctx context.Context, exitSide broker.OrderSide) error {
 func (b *Bot) executeExitOrder(ctx context.Context, side broker.OrderSide, size decimal.Decimal) (broker.Order, error) {
 	var empty broker.Order
 
-	if _, err := b.dealer.CancelOrders(ctx); err != nil {
+	if err := b.cancelOpenOrders(ctx); err != nil {
 		return empty, err
 	}
 
@@ -192,6 +193,21 @@ func (b *Bot) executeExitOrder(ctx context.Context, side broker.OrderSide, size
 	return *placed, err
 }
 
+// cancelOpenOrders cancels the resting orders for the bot asset, such as the stop of an open position.
+func (b *Bot) cancelOpenOrders(ctx context.Context) error {
+	filter := broker.OrderFilter{Asset: b.Asset, States: []broker.OrderState{broker.OrderOpen}}
+	orders, _, err := b.dealer.ListOrders(ctx, &filter, nil)
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		if _, _, err := b.dealer.CancelOrder(ctx, orders[i].ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (b *Bot) enter(ctx context.Context, enterSide broker.OrderSide, price decimal.Decimal) error {
 	if enterSide == 0 {
 		return nil
@@ -223,46 +239,42 @@ func (b *Bot) enter(ctx context.Context, enterSide broker.OrderSide, price decim
 }
 
 func (b *Bot) executeEnterOrder(ctx context.Context, side broker.OrderSide, price, size, risk decimal.Decimal) (broker.BracketOrder, error) {
-	var bracket, empty broker.BracketOrder
+	var empty broker.BracketOrder
 
-	if _, err := b.dealer.CancelOrders(ctx); err != nil {
-		return empty, err
+	bracket := broker.BracketOrder{
+		Enter: broker.Order{
+			Asset: b.Asset,
+			Type:  broker.Market,
+			Side:  side,
+			Size:  size,
+		},
 	}
 
-	order := broker.Order{
-		Asset: b.Asset,
-		Type:  broker.Market,
-		Side:  side,
-		Size:  size,
-	}
-	enterPlaced, _, err := b.dealer.PlaceOrder(ctx, order)
-	if err != nil {
-		return empty, err
-	}
-	bracket = broker.BracketOrder{Enter: *enterPlaced}
-
 	stop := broker.Order{
 		Asset:      b.Asset,
-		Type:       broker.Limit,
+		Type:       broker.Stop,
 		Side:       side.Opposite(),
 		Size:       size,
 		ReduceOnly: true,
 	}
 	if side == broker.Buy {
-		stop.LimitPrice = price.Sub(risk)
+		stop.StopPrice = price.Sub(risk)
 	} else {
-		stop.LimitPrice = price.Add(risk)
+		stop.StopPrice = price.Add(risk)
 	}
-	if !stop.LimitPrice.IsPositive() {
-		return bracket, nil
+	if stop.StopPrice.IsPositive() {
+		bracket.Stop = stop
 	}
-	stopPlaced, _, err := b.dealer.PlaceOrder(ctx, stop)
+
+	placed, _, err := b.dealer.PlaceBracketOrder(ctx, bracket)
 	if err != nil {
-		return bracket, err
+		return empty, err
+	}
+	if placed == nil {
+		return empty, nil
 	}
-	bracket.Stop = *stopPlaced
 
-	return bracket, nil
+	return *placed, nil
 }
 
 func filterPositions(positions []broker.Position, asset market.Asset, side broker.OrderSide, state broker.PositionState) []broker.Position {
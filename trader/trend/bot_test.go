@@ -39,8 +39,11 @@ func TestBot_executeExitOrder(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			giveStop := broker.Order{ID: "stop"}
 			var dealer broker.MockDealer
-			dealer.On("CancelOrders", context.Background()).Return((*web.Response)(nil), nil)
+			dealer.On("ListOrders", context.Background(), &broker.OrderFilter{States: []broker.OrderState{broker.OrderOpen}}, (*web.ListOpts)(nil)).
+				Return([]broker.Order{giveStop}, (*web.Response)(nil), nil)
+			dealer.On("CancelOrder", context.Background(), giveStop.ID).Return(&giveStop, (*web.Response)(nil), nil)
 			dealer.On("PlaceOrder", context.Background(), tt.want).Return(&tt.want, (*web.Response)(nil), nil)
 			bot := Bot{dealer: &dealer}
 			act, err := bot.executeExitOrder(context.Background(), tt.giveSide, tt.giveSize)
@@ -74,8 +77,8 @@ func TestBot_executeEnterOrder(t *testing.T) {
 				},
 				Stop: broker.Order{
 					Side:       broker.Buy,
-					Type:       broker.Limit,
-					LimitPrice: dec.New(11),
+					Type:       broker.Stop,
+					StopPrice:  dec.New(11),
 					Size:       dec.New(2),
 					ReduceOnly: true,
 				},
@@ -95,8 +98,8 @@ func TestBot_executeEnterOrder(t *testing.T) {
 				},
 				Stop: broker.Order{
 					Side:       broker.Sell,
-					Type:       broker.Limit,
-					LimitPrice: dec.New(9),
+					Type:       broker.Stop,
+					StopPrice:  dec.New(9),
 					Size:       dec.New(2),
 					ReduceOnly: true,
 				},
@@ -120,9 +123,7 @@ func TestBot_executeEnterOrder(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var dealer broker.MockDealer
-			dealer.On("CancelOrders", context.Background()).Return((*web.Response)(nil), nil)
-			dealer.On("PlaceOrder", context.Background(), tt.want.Enter).Return(&tt.want.Enter, (*web.Response)(nil), nil).Once()
-			dealer.On("PlaceOrder", context.Background(), tt.want.Stop).Return(&tt.want.Stop, (*web.Response)(nil), nil).Maybe()
+			dealer.On("PlaceBracketOrder", context.Background(), tt.want).Return(&tt.want, (*web.Response)(nil), nil).Once()
 			bot := Bot{dealer: &dealer}
 			act, err := bot.executeEnterOrder(context.Background(), tt.giveSide, tt.givePrice, tt.giveSize, tt.giveRisk)
 			assert.NoError(t, err)
@@ -283,9 +284,10 @@ func TestBot_getOpenPosition(t *testing.T) {
 		{ID: "3", OpenedAt: fixed, ClosedAt: time.Now(), Side: broker.Buy},
 	}
 	var dealer broker.MockDealer
-	dealer.On("ListPositions", context.Background(), (*web.ListOpts)(nil)).Return(givePositions, (*web.Response)(nil), nil)
-
 	giveSide := broker.Sell
+	wantFilter := &broker.PositionFilter{Side: giveSide, States: []broker.PositionState{broker.PositionOpen}}
+	dealer.On("ListPositions", context.Background(), wantFilter, (*web.ListOpts)(nil)).Return(givePositions, (*web.Response)(nil), nil)
+
 	want := broker.Position{ID: "2", OpenedAt: fixed, Side: broker.Sell}
 
 	bot := Bot{dealer: &dealer}
@@ -57,7 +57,7 @@ func TestBotWithCrossPredicter(t *testing.T) {
 
 	assert.NoError(t, bot.Close(context.Background()))
 
-	roundturns, _, _ := dealer.ListRoundTurns(context.Background(), nil)
+	roundturns, _, _ := dealer.ListRoundTurns(context.Background(), nil, nil)
 	equity := dealer.EquityHistory()
 	report := perf.NewPerformanceReport(roundturns, equity)
 	perf.PrintSummary(report)
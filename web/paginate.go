@@ -0,0 +1,32 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package web
+
+// Paginate returns the page of items selected by the list options, and the pagination information of the page.
+// Pages are numbered from 1 and a zero PageNum returns the first page.
+// A nil opts or zero PageSize returns all items in a single page.
+func Paginate[T any](items []T, opts *ListOpts) ([]T, Page) {
+	if opts == nil || opts.PageSize <= 0 {
+		return items, Page{PageSize: len(items), PageNum: 1, PagesTotal: 1}
+	}
+
+	page := Page{
+		PageSize:   opts.PageSize,
+		PageNum:    opts.PageNum,
+		PagesTotal: (len(items) + opts.PageSize - 1) / opts.PageSize,
+	}
+	if page.PageNum < 1 {
+		page.PageNum = 1
+	}
+
+	start := (page.PageNum - 1) * page.PageSize
+	if start >= len(items) {
+		return items[:0], page
+	}
+	end := start + page.PageSize
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end], page
+}
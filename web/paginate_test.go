@@ -0,0 +1,53 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		name     string
+		giveOpts *ListOpts
+		want     []int
+		wantPage Page
+	}{
+		{
+			name:     "nil opts",
+			giveOpts: nil,
+			want:     []int{1, 2, 3, 4, 5},
+			wantPage: Page{PageSize: 5, PageNum: 1, PagesTotal: 1},
+		},
+		{
+			name:     "first page",
+			giveOpts: &ListOpts{PageSize: 2},
+			want:     []int{1, 2},
+			wantPage: Page{PageSize: 2, PageNum: 1, PagesTotal: 3},
+		},
+		{
+			name:     "last partial page",
+			giveOpts: &ListOpts{PageSize: 2, PageNum: 3},
+			want:     []int{5},
+			wantPage: Page{PageSize: 2, PageNum: 3, PagesTotal: 3},
+		},
+		{
+			name:     "page beyond total",
+			giveOpts: &ListOpts{PageSize: 2, PageNum: 4},
+			want:     []int{},
+			wantPage: Page{PageSize: 2, PageNum: 4, PagesTotal: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, page := Paginate(items, tt.giveOpts)
+			assert.Equal(t, tt.want, act)
+			assert.Equal(t, tt.wantPage, page)
+		})
+	}
+}